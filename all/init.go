@@ -3,4 +3,6 @@ package all
 import (
 	_ "github.com/yacchi/lambda-http-adaptor/aws"
 	_ "github.com/yacchi/lambda-http-adaptor/azure"
+	_ "github.com/yacchi/lambda-http-adaptor/gcp"
+	_ "github.com/yacchi/lambda-http-adaptor/local"
 )
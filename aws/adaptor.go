@@ -2,21 +2,32 @@ package aws
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-lambda-go/lambda"
 	"github.com/aws/aws-lambda-go/lambda/handlertrace"
+	"github.com/yacchi/lambda-http-adaptor/log"
 	"github.com/yacchi/lambda-http-adaptor/registry"
 	"github.com/yacchi/lambda-http-adaptor/types"
+	"io"
 	"net/http"
 	"os"
+	"sync"
+	"time"
 )
 
 var DEBUGDumpPayload = os.Getenv("DEBUG_DUMP_PAYLOAD")
 var LambdaInvokeMode = os.Getenv("LAMBDA_INVOKE_MODE")
 
-// WebsocketResponseMode
+// WebsocketResponseMode Default WebsocketResponseType of the websocket routes.
 // * return - use lambda return value as response
 // * post_to_connection - use PostToConnection API to send response
+// * buffered - use PostToConnection API to send the response buffered until Flush or the handler returns
+//
+// It is read at each invocation of the handlers without WithWebsocketResponse, and unknown values are warned
+// and treated as post_to_connection. Each route can override it with WithWebsocketRouteResponse.
 var WebsocketResponseMode = os.Getenv("WEBSOCKET_RESPONSE_MODE")
 
 type LambdaIntegrationType int
@@ -28,8 +39,34 @@ const (
 	APIGatewayHTTPIntegration
 	ALBTargetGroupIntegration
 	LambdaFunctionURLIntegration
+	SQSIntegration
+	SNSIntegration
+	EventBridgeIntegration
 )
 
+func (t LambdaIntegrationType) String() string {
+	switch t {
+	case APIGatewayRESTIntegration:
+		return "rest_api"
+	case APIGatewayWebsocketIntegration:
+		return "websocket_api"
+	case APIGatewayHTTPIntegration:
+		return "http_api"
+	case ALBTargetGroupIntegration:
+		return "alb_target_group"
+	case LambdaFunctionURLIntegration:
+		return "function_url"
+	case SQSIntegration:
+		return "sqs"
+	case SNSIntegration:
+		return "sns"
+	case EventBridgeIntegration:
+		return "eventbridge"
+	default:
+		return "unknown"
+	}
+}
+
 type integrationTypeChecker struct {
 	// 'resource' parameter only has REST API event.
 	Resource *string `json:"resource"`
@@ -48,10 +85,33 @@ type integrationTypeChecker struct {
 	RequestContext struct {
 		// 'connectionID' parameter nly has API Gateway Websocket mode event.
 		ConnectionID *string `json:"connectionId"`
+		// 'domainName' parameter of Function URLs is always '<url-id>.lambda-url.<region>.on.aws'.
+		DomainName string `json:"domainName"`
 	} `json:"requestContext"`
+
+	// 'Records' parameter only has events from event sources such as SQS and SNS.
+	// SQS uses 'eventSource' and SNS uses 'EventSource', both are matched case-insensitively.
+	Records []struct {
+		EventSource string `json:"eventSource"`
+	} `json:"Records"`
+
+	// 'detail-type' and 'source' parameters only have EventBridge event.
+	DetailType *string `json:"detail-type"`
+	Source     *string `json:"source"`
 }
 
 func (t integrationTypeChecker) IntegrationType() LambdaIntegrationType {
+	if 0 < len(t.Records) {
+		switch t.Records[0].EventSource {
+		case "aws:sqs":
+			return SQSIntegration
+		case "aws:sns":
+			return SNSIntegration
+		}
+	}
+	if t.DetailType != nil && t.Source != nil {
+		return EventBridgeIntegration
+	}
 	if t.Resource != nil {
 		if t.RequestContext.ConnectionID == nil {
 			return APIGatewayRESTIntegration
@@ -63,6 +123,12 @@ func (t integrationTypeChecker) IntegrationType() LambdaIntegrationType {
 		return APIGatewayWebsocketIntegration
 	}
 	if t.Version != nil {
+		if t.RequestContext.DomainName != "" {
+			if isFunctionURLDomain(t.RequestContext.DomainName) {
+				return LambdaFunctionURLIntegration
+			}
+			return APIGatewayHTTPIntegration
+		}
 		if t.RouteKey == "$default" && t.PathParameters == nil {
 			return LambdaFunctionURLIntegration
 		}
@@ -87,34 +153,247 @@ func LambdaDetector() bool {
 	return false
 }
 
+// DefaultShutdownTimeout Lambda gives 500ms to the runtime to shut down when only internal extensions are registered.
+const DefaultShutdownTimeout = 500 * time.Millisecond
+
+// ErrAdaptorClosed Returned by the invocation after LambdaAdaptor.Shutdown is called.
+var ErrAdaptorClosed = errors.New("aws_lambda: adaptor closed")
+
 type LambdaAdaptor struct {
-	h *LambdaHandler
+	h               *LambdaHandler
+	shutdownTimeout time.Duration
+
+	mu         sync.Mutex
+	closed     bool
+	inflight   sync.WaitGroup
+	onShutdown []func()
+	done       chan struct{}
+	doneOnce   sync.Once
+
+	onInit            []func(ctx context.Context) error
+	beforeFirstInvoke []func(ctx context.Context) error
+	afterRestore      []func(ctx context.Context) error
+	// firstInvokeMu Guards the number of the hooks that succeeded, to retry the failed hook on the next invocation.
+	firstInvokeMu         sync.Mutex
+	afterRestoreDone      int
+	beforeFirstInvokeDone int
 }
 
-func (l LambdaAdaptor) ListenAndServe() error {
+// LambdaAdaptorOption Option of LambdaAdaptor.
+// It can be passed to the ListenAndServeWithOptions with LambdaHandlerOption.
+type LambdaAdaptorOption func(l *LambdaAdaptor)
+
+// WithOnShutdown Register a function to call on shutdown. See also LambdaAdaptor.RegisterOnShutdown.
+func WithOnShutdown(f func()) LambdaAdaptorOption {
+	return func(l *LambdaAdaptor) {
+		l.RegisterOnShutdown(f)
+	}
+}
+
+// WithShutdownTimeout Set the time to wait for in-flight invocations when SIGTERM is received.
+// The default is DefaultShutdownTimeout.
+func WithShutdownTimeout(d time.Duration) LambdaAdaptorOption {
+	return func(l *LambdaAdaptor) {
+		l.shutdownTimeout = d
+	}
+}
+
+// WithOnInit Register a function to call in the init phase, before the runtime starts to receive invocations.
+// If the function returns an error, ListenAndServe returns it, and Lambda reports the init error.
+func WithOnInit(f func(ctx context.Context) error) LambdaAdaptorOption {
+	return func(l *LambdaAdaptor) {
+		l.onInit = append(l.onInit, f)
+	}
+}
+
+// WithBeforeFirstInvoke Register a function to call before the first invocation is handled.
+// For provisioned concurrency and SnapStart, it runs on the first request instead of the init phase.
+// If the function returns an error, the invocation fails with it, and the function is retried on the next invocation.
+func WithBeforeFirstInvoke(f func(ctx context.Context) error) LambdaAdaptorOption {
+	return func(l *LambdaAdaptor) {
+		l.beforeFirstInvoke = append(l.beforeFirstInvoke, f)
+	}
+}
+
+// WithAfterRestore Register a function to call after the execution environment is restored from a SnapStart snapshot,
+// such as refreshing credentials or unique values captured in the snapshot.
+// Since aws-lambda-go does not expose the restore phase of the Runtime API, it runs before the first invocation
+// of the restored environment, and before the functions of WithBeforeFirstInvoke.
+// If the function returns an error, the invocation fails with it, and the function is retried on the next invocation.
+func WithAfterRestore(f func(ctx context.Context) error) LambdaAdaptorOption {
+	return func(l *LambdaAdaptor) {
+		l.afterRestore = append(l.afterRestore, f)
+	}
+}
+
+// RegisterOnShutdown Register a function to call on Shutdown, after in-flight invocations are finished.
+// This can be used to close connection pools or flush telemetry exporters.
+//
+// When any function is registered before ListenAndServe, the adaptor registers an internal extension
+// to enable SIGTERM from the Lambda runtime, and shuts down gracefully on it.
+func (l *LambdaAdaptor) RegisterOnShutdown(f func()) {
+	l.mu.Lock()
+	l.onShutdown = append(l.onShutdown, f)
+	l.mu.Unlock()
+}
+
+// ListenAndServe Start the Lambda runtime loop.
+// After Shutdown is called, ListenAndServe returns http.ErrServerClosed.
+func (l *LambdaAdaptor) ListenAndServe() error {
 	ctx := context.Background()
 	if DEBUGDumpPayload != "" && (DEBUGDumpPayload == "1" || DEBUGDumpPayload == "true") {
 		ctx = handlertrace.NewContext(ctx, handlertrace.HandlerTrace{
 			RequestEvent: func(ctx context.Context, payload interface{}) {
-				fmt.Printf("Request payload: %s\n", payload)
+				log.FromContext(ctx).Info("aws_lambda: request payload", "payload", fmt.Sprintf("%s", payload))
 			},
 			ResponseEvent: func(ctx context.Context, payload interface{}) {
-				fmt.Printf("Response payload: %+v\n", payload)
+				log.FromContext(ctx).Info("aws_lambda: response payload", "payload", fmt.Sprintf("%+v", payload))
 			},
 		})
 	}
-	lambda.StartHandlerFunc(l.h.Invoke, lambda.WithContext(ctx))
+
+	for _, f := range l.onInit {
+		if err := f(ctx); err != nil {
+			return fmt.Errorf("aws_lambda: init: %w", err)
+		}
+	}
+
+	options := []lambda.Option{lambda.WithContext(ctx)}
+
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return http.ErrServerClosed
+	}
+	if 0 < len(l.onShutdown) {
+		options = append(options, lambda.WithEnableSIGTERM(l.shutdownOnSIGTERM))
+	}
+	l.mu.Unlock()
+
+	go lambda.StartHandlerFunc(l.invoke, options...)
+
+	<-l.done
+	return http.ErrServerClosed
+}
+
+// Shutdown Stop accepting new invocations, and wait for in-flight invocations.
+// Then the functions registered by RegisterOnShutdown are called.
+// If the context expires before in-flight invocations are finished, the context's error is returned.
+func (l *LambdaAdaptor) Shutdown(ctx context.Context) error {
+	l.mu.Lock()
+	l.closed = true
+	hooks := l.onShutdown
+	l.onShutdown = nil
+	l.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		l.inflight.Wait()
+		close(drained)
+	}()
+
+	var err error
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	for _, f := range hooks {
+		f()
+	}
+
+	l.doneOnce.Do(func() {
+		close(l.done)
+	})
+	return err
+}
+
+func (l *LambdaAdaptor) shutdownOnSIGTERM() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+	if err := l.Shutdown(ctx); err != nil {
+		log.Default().Warn("aws_lambda: shutdown", "error", err)
+	}
+}
+
+func (l *LambdaAdaptor) invoke(ctx context.Context, payload json.RawMessage) (any, error) {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil, ErrAdaptorClosed
+	}
+	l.inflight.Add(1)
+	l.mu.Unlock()
+
+	ctx, _ = withInvocation(ctx)
+
+	if err := l.runFirstInvokeHooks(ctx); err != nil {
+		l.inflight.Done()
+		return nil, err
+	}
+
+	res, err := l.h.Invoke(ctx, payload)
+
+	// The streaming response is still being written after the invocation returns.
+	if stream, ok := res.(*events.LambdaFunctionURLStreamingResponse); ok && err == nil {
+		stream.Body = &inflightReader{r: stream.Body, done: l.inflight.Done}
+		return res, nil
+	}
+
+	l.inflight.Done()
+	return res, err
+}
+
+// runFirstInvokeHooks Run the hooks which have not succeeded yet.
+// The environment must not serve requests until the hooks succeed, such as with the stale state of the snapshot,
+// so a failed hook fails the invocation and is retried on the next one.
+func (l *LambdaAdaptor) runFirstInvokeHooks(ctx context.Context) error {
+	l.firstInvokeMu.Lock()
+	defer l.firstInvokeMu.Unlock()
+
+	if LambdaEnvironment.IsSnapStart() {
+		for ; l.afterRestoreDone < len(l.afterRestore); l.afterRestoreDone++ {
+			if err := l.afterRestore[l.afterRestoreDone](ctx); err != nil {
+				return fmt.Errorf("aws_lambda: after restore: %w", err)
+			}
+		}
+	}
+	for ; l.beforeFirstInvokeDone < len(l.beforeFirstInvoke); l.beforeFirstInvokeDone++ {
+		if err := l.beforeFirstInvoke[l.beforeFirstInvokeDone](ctx); err != nil {
+			return fmt.Errorf("aws_lambda: before first invoke: %w", err)
+		}
+	}
 	return nil
 }
 
-func (l LambdaAdaptor) Shutdown(ctx context.Context) error {
-	return fmt.Errorf("aws_lambda: unsupported shutdown")
+// inflightReader Mark the invocation finished when the body is read to the end.
+type inflightReader struct {
+	r    io.Reader
+	done func()
+	once sync.Once
+}
+
+func (r *inflightReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil {
+		r.once.Do(r.done)
+	}
+	return n, err
 }
 
 func NewLambdaAdaptor(addr string, h http.Handler, options []interface{}) types.Adaptor {
-	return &LambdaAdaptor{
-		h: NewLambdaHandlerWithOption(h, options),
+	l := &LambdaAdaptor{
+		h:               NewLambdaHandlerWithOption(h, options),
+		shutdownTimeout: DefaultShutdownTimeout,
+		done:            make(chan struct{}),
+	}
+	for _, opt := range options {
+		if adaptorOpt, ok := opt.(LambdaAdaptorOption); ok {
+			adaptorOpt(l)
+		}
 	}
+	return l
 }
 
 func init() {
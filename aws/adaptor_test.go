@@ -0,0 +1,217 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// lockedBuffer bytes.Buffer written by the other goroutines.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func startTestAdaptor(t *testing.T, h http.Handler, options ...interface{}) *awstest.Runtime {
+	rt, _, _ := serveTestAdaptor(t, h, options...)
+	return rt
+}
+
+func serveTestAdaptor(t *testing.T, h http.Handler, options ...interface{}) (*awstest.Runtime, *LambdaAdaptor, <-chan error) {
+	rt := awstest.NewRuntime()
+	t.Cleanup(rt.Close)
+	t.Setenv(awstest.RuntimeAPIEnvKey, rt.Address())
+
+	adaptor := NewLambdaAdaptor("", h, options).(*LambdaAdaptor)
+	served := make(chan error, 1)
+	go func() {
+		served <- adaptor.ListenAndServe()
+	}()
+	return rt, adaptor, served
+}
+
+func TestLambdaAdaptor_ListenAndServe(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(writer http.ResponseWriter, request *http.Request) {
+		lc, ok := lambdacontext.FromContext(request.Context())
+		assert.True(t, ok)
+		rc, ok := GetRequestContext(request.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "http-request-id", rc.RequestID())
+		assert.Equal(t, awstest.DefaultFunctionARN, lc.InvokedFunctionArn)
+		assert.NotEmpty(t, request.Header.Get(types.HTTPHeaderXRayTraceIDKey))
+
+		writer.Header().Set("Content-Type", "text/plain")
+		writer.Write([]byte(request.URL.Query().Get("message")))
+	})
+	mux.HandleFunc("/stream", func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte("chunk1,"))
+		writer.(http.Flusher).Flush()
+		writer.Write([]byte("chunk2"))
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("buffered", func(t *testing.T) {
+		defer func(v string) { DEBUGDumpPayload = v }(DEBUGDumpPayload)
+		DEBUGDumpPayload = "true"
+
+		// the payloads are dumped by the runtime loop
+		dump := &lockedBuffer{}
+		defaultLogger := log.Default()
+		log.SetDefault(slog.New(slog.NewTextHandler(dump, nil)))
+		defer log.SetDefault(defaultLogger)
+
+		rt := startTestAdaptor(t, mux)
+
+		event := events.APIGatewayV2HTTPRequest{
+			Version:        "2.0",
+			RawPath:        "/echo",
+			RawQueryString: "message=hello",
+		}
+		event.RequestContext.RequestID = "http-request-id"
+		event.RequestContext.HTTP.Method = http.MethodGet
+		payload, _ := json.Marshal(event)
+
+		res, err := rt.Invoke(ctx, payload)
+		assert.NoError(t, err)
+		assert.Nil(t, res.Error)
+
+		var r events.APIGatewayV2HTTPResponse
+		assert.NoError(t, json.Unmarshal(res.Payload, &r))
+		assert.Equal(t, http.StatusOK, r.StatusCode)
+		assert.Equal(t, "hello", r.Body)
+
+		dumped := dump.String()
+		assert.Contains(t, dumped, "aws_lambda: request payload")
+		assert.Contains(t, dumped, "message=hello")
+		assert.Contains(t, dumped, "aws_lambda: response payload")
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		rt := startTestAdaptor(t, mux, WithResponseStream())
+
+		res, err := rt.Invoke(ctx, newFunctionURLEvent(t, http.MethodGet, "/stream"))
+		assert.NoError(t, err)
+		assert.Nil(t, res.Error)
+		assert.Equal(t, "application/vnd.awslambda.http-integration-response", res.ContentType)
+
+		prelude, body, found := bytes.Cut(res.Payload, make([]byte, 8))
+		assert.True(t, found)
+		assert.JSONEq(t, `{"statusCode":200,"headers":{"Content-Type":"text/plain; charset=utf8"}}`, string(prelude))
+		assert.Equal(t, "chunk1,chunk2", string(body))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		rt := startTestAdaptor(t, mux, WithoutNonHTTPEventPassThrough())
+
+		res, err := rt.Invoke(ctx, []byte(`{"unknown": true}`))
+		assert.NoError(t, err)
+		assert.NotNil(t, res.Error)
+	})
+}
+
+func TestLambdaAdaptor_Shutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(writer http.ResponseWriter, request *http.Request) {
+		close(started)
+		<-release
+		writer.Write([]byte("done"))
+	})
+
+	var hooked atomic.Bool
+	rt, adaptor, served := serveTestAdaptor(t, mux, WithOnShutdown(func() {
+		hooked.Store(true)
+	}))
+
+	inflight := make(chan *awstest.Result, 1)
+	go func() {
+		res, err := rt.Invoke(ctx, newFunctionURLEvent(t, http.MethodGet, "/slow"))
+		assert.NoError(t, err)
+		inflight <- res
+	}()
+	<-started
+
+	// SIGTERM is enabled by the internal extension when shutdown hooks are registered.
+	assert.Contains(t, rt.Extensions(), "GoLangEnableSIGTERM")
+
+	shutdown := make(chan error, 1)
+	go func() {
+		shutdown <- adaptor.Shutdown(ctx)
+	}()
+
+	select {
+	case <-shutdown:
+		t.Fatal("shutdown returned before in-flight invocation finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.False(t, hooked.Load())
+
+	close(release)
+	res := <-inflight
+	assert.Nil(t, res.Error)
+	assert.NoError(t, <-shutdown)
+	assert.True(t, hooked.Load())
+	assert.ErrorIs(t, <-served, http.ErrServerClosed)
+
+	// new invocations are rejected after shutdown
+	res, err := rt.Invoke(ctx, newFunctionURLEvent(t, http.MethodGet, "/slow"))
+	assert.NoError(t, err)
+	if assert.NotNil(t, res.Error) {
+		assert.Equal(t, ErrAdaptorClosed.Error(), res.Error.Message)
+	}
+}
+
+func TestLambdaAdaptor_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	started := make(chan struct{})
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(writer http.ResponseWriter, request *http.Request) {
+		close(started)
+		<-release
+	})
+
+	rt, adaptor, _ := serveTestAdaptor(t, mux)
+	go func() {
+		_, _ = rt.Invoke(context.Background(), newFunctionURLEvent(t, http.MethodGet, "/slow"))
+	}()
+	<-started
+
+	// no extension is registered without shutdown hooks
+	assert.Empty(t, rt.Extensions())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, adaptor.Shutdown(ctx), context.DeadlineExceeded)
+}
@@ -84,6 +84,8 @@ func NewALBTargetGroupRequest(ctx context.Context, e *events.ALBTargetGroupReque
 	r.RequestURI = r.URL.RequestURI()
 
 	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newALBTargetGroupRequestContext(ctx, header)))
+	r = withRequestLogger(r, "")
 
 	return
 }
@@ -93,7 +95,7 @@ func ALBTargetResponse(w *ResponseWriter, multiValue bool) (r *events.ALBTargetG
 	r = &events.ALBTargetGroupResponse{
 		StatusCode:        w.status,
 		StatusDescription: strconv.Itoa(w.status) + " " + http.StatusText(w.status),
-		IsBase64Encoded:   utils.IsBinaryContent(w.Header()),
+		IsBase64Encoded:   w.isBinary(),
 	}
 
 	if multiValue {
@@ -77,6 +77,8 @@ func NewHTTPAPIRequest(ctx context.Context, e *events.APIGatewayV2HTTPRequest) (
 	r.RequestURI = r.URL.RequestURI()
 
 	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newHTTPAPIRequestContext(e)))
+	r = withRequestLogger(r, e.RouteKey)
 
 	if r.Header.Get(types.HTTPHeaderXRayTraceIDKey) == "" {
 		if traceID := ctx.Value(types.AWSXRayTraceIDContextKey); traceID != nil {
@@ -92,7 +94,7 @@ func NewHTTPAPIRequest(ctx context.Context, e *events.APIGatewayV2HTTPRequest) (
 func HTTPAPIResponse(w *ResponseWriter) (r *events.APIGatewayV2HTTPResponse, err error) {
 	r = &events.APIGatewayV2HTTPResponse{
 		StatusCode:      w.status,
-		IsBase64Encoded: utils.IsBinaryContent(w.Header()),
+		IsBase64Encoded: w.isBinary(),
 		Headers:         utils.SemicolonSeparatedHeaderMap(w.headers),
 	}
 
@@ -90,6 +90,8 @@ func NewRESTAPIRequest(ctx context.Context, e *events.APIGatewayProxyRequest) (r
 	}
 
 	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newRESTAPIRequestContext(e)))
+	r = withRequestLogger(r, restAPIRoute(e))
 
 	return
 }
@@ -98,7 +100,7 @@ func NewRESTAPIRequest(ctx context.Context, e *events.APIGatewayProxyRequest) (r
 func RESTAPITargetResponse(w *ResponseWriter, multiValue bool) (r *events.APIGatewayProxyResponse, err error) {
 	r = &events.APIGatewayProxyResponse{
 		StatusCode:      w.status,
-		IsBase64Encoded: utils.IsBinaryContent(w.Header()),
+		IsBase64Encoded: w.isBinary(),
 	}
 
 	if multiValue {
@@ -116,3 +118,11 @@ func RESTAPITargetResponse(w *ResponseWriter, multiValue bool) (r *events.APIGat
 	w.Done()
 	return
 }
+
+// restAPIRoute Route of the request with the resource path, such as 'GET /items/{id}'.
+func restAPIRoute(e *events.APIGatewayProxyRequest) string {
+	if e.Resource == "" {
+		return ""
+	}
+	return e.HTTPMethod + " " + e.Resource
+}
@@ -13,20 +13,24 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/PaesslerAG/jsonpath"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
 	"github.com/aws/aws-sdk-go/aws/endpoints"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
 	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/log"
 	"github.com/yacchi/lambda-http-adaptor/types"
 	"github.com/yacchi/lambda-http-adaptor/utils"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
+	"time"
 )
 
 var (
@@ -34,6 +38,36 @@ var (
 	DefaultRouteSelectionExpression = "$request.body.action"
 )
 
+// WebsocketResponseType How the response of the websocket route is sent to the client.
+type WebsocketResponseType string
+
+const (
+	// WebsocketReturnResponse Return the response as the Lambda return value. The route must be a two-way route.
+	WebsocketReturnResponse WebsocketResponseType = "return"
+	// WebsocketPostToConnectionResponse Post each Write to the connection with the API Gateway management API.
+	WebsocketPostToConnectionResponse WebsocketResponseType = "post_to_connection"
+	// WebsocketBufferedResponse Post the writes to the connection as a single message on Flush or when the handler returns.
+	WebsocketBufferedResponse WebsocketResponseType = "buffered"
+)
+
+// ParseWebsocketResponseType Parse the value of WEBSOCKET_RESPONSE_MODE. The default is WebsocketPostToConnectionResponse.
+func ParseWebsocketResponseType(s string) WebsocketResponseType {
+	t, _ := parseWebsocketResponseType(s)
+	return t
+}
+
+// parseWebsocketResponseType Parse the value, and report whether it is empty or a known WebsocketResponseType.
+func parseWebsocketResponseType(s string) (WebsocketResponseType, bool) {
+	switch t := WebsocketResponseType(s); t {
+	case WebsocketReturnResponse, WebsocketPostToConnectionResponse, WebsocketBufferedResponse:
+		return t, true
+	case "":
+		return WebsocketPostToConnectionResponse, true
+	default:
+		return WebsocketPostToConnectionResponse, false
+	}
+}
+
 // NewWebsocketRequest Lambda event type to http.Request converter for API Gateway with REST API mode.
 func NewWebsocketRequest(ctx context.Context, e *events.APIGatewayWebsocketProxyRequest, pathPrefix string) (r *http.Request, multiValue bool, err error) {
 	var (
@@ -112,12 +146,29 @@ func NewWebsocketRequest(ctx context.Context, e *events.APIGatewayWebsocketProxy
 	}
 
 	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newWebsocketRequestContext(e)))
+	r = withRequestLogger(r, e.RequestContext.RouteKey)
 
 	return
 }
 
+// ErrConnectionGone The connection is already disconnected. Errors of APIGatewayManagementAPI for GoneException wrap it.
+var ErrConnectionGone = errors.New("websocket: connection is gone")
+
+// ConnectionInfo Information of a connection returned by the API Gateway management API.
+type ConnectionInfo struct {
+	ConnectedAt  time.Time
+	LastActiveAt time.Time
+	SourceIP     string
+	UserAgent    string
+}
+
+// APIGatewayManagementAPI Client of the API Gateway management API to send messages to the clients and manage the connections.
 type APIGatewayManagementAPI interface {
 	PostToConnection(ctx context.Context, connectionID string, data []byte) (err error)
+	// DeleteConnection Disconnect the client. It can not be used in the $connect route, since the connection is not established yet.
+	DeleteConnection(ctx context.Context, connectionID string) (err error)
+	GetConnection(ctx context.Context, connectionID string) (info *ConnectionInfo, err error)
 }
 
 type v1api struct {
@@ -129,7 +180,85 @@ func (v *v1api) PostToConnection(ctx context.Context, connectionID string, data
 		ConnectionId: aws.String(connectionID),
 		Data:         data,
 	})
-	return
+	return v1Error(err)
+}
+
+func (v *v1api) DeleteConnection(ctx context.Context, connectionID string) (err error) {
+	_, err = v.ApiGatewayManagementApi.DeleteConnectionWithContext(ctx, &apigatewaymanagementapi.DeleteConnectionInput{
+		ConnectionId: aws.String(connectionID),
+	})
+	return v1Error(err)
+}
+
+func (v *v1api) GetConnection(ctx context.Context, connectionID string) (*ConnectionInfo, error) {
+	out, err := v.ApiGatewayManagementApi.GetConnectionWithContext(ctx, &apigatewaymanagementapi.GetConnectionInput{
+		ConnectionId: aws.String(connectionID),
+	})
+	if err != nil {
+		return nil, v1Error(err)
+	}
+	info := &ConnectionInfo{
+		ConnectedAt:  aws.TimeValue(out.ConnectedAt),
+		LastActiveAt: aws.TimeValue(out.LastActiveAt),
+	}
+	if out.Identity != nil {
+		info.SourceIP = aws.StringValue(out.Identity.SourceIp)
+		info.UserAgent = aws.StringValue(out.Identity.UserAgent)
+	}
+	return info, nil
+}
+
+func v1Error(err error) error {
+	var aerr awserr.Error
+	if errors.As(err, &aerr) && aerr.Code() == apigatewaymanagementapi.ErrCodeGoneException {
+		return fmt.Errorf("%w: %w", ErrConnectionGone, err)
+	}
+	return err
+}
+
+// WebsocketConnection Connection of the current websocket request.
+// The client of the API Gateway management API is provided on the first use.
+type WebsocketConnection struct {
+	ID      string
+	provide func() (APIGatewayManagementAPI, error)
+}
+
+// Client Get the client of the API Gateway management API.
+func (c *WebsocketConnection) Client() (APIGatewayManagementAPI, error) {
+	return c.provide()
+}
+
+// Post Send the message to the connection.
+func (c *WebsocketConnection) Post(ctx context.Context, data []byte) error {
+	client, err := c.Client()
+	if err != nil {
+		return err
+	}
+	return client.PostToConnection(ctx, c.ID, data)
+}
+
+// Close Disconnect the connection.
+func (c *WebsocketConnection) Close(ctx context.Context) error {
+	client, err := c.Client()
+	if err != nil {
+		return err
+	}
+	return client.DeleteConnection(ctx, c.ID)
+}
+
+// Info Get the information of the connection, such as the connected time and the identity of the client.
+func (c *WebsocketConnection) Info(ctx context.Context) (*ConnectionInfo, error) {
+	client, err := c.Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.GetConnection(ctx, c.ID)
+}
+
+type websocketConnectionContextKey struct{}
+
+func newWebsocketConnectionContext(ctx context.Context, conn *WebsocketConnection) context.Context {
+	return context.WithValue(ctx, websocketConnectionContextKey{}, conn)
 }
 
 // NewAPIGatewayManagementClientV1 creates a new API Gateway Management Client instance from the provided parameters. The
@@ -154,10 +283,23 @@ func NewAPIGatewayManagementClientV1(sess *session.Session, domain, stage string
 }
 
 // WebsocketResponse Response writer for API Gateway with REST API mode.
+// In the buffered mode, the buffered message is posted, and the failure is reported as 500 Internal Server Error.
 func WebsocketResponse(w *WebsocketResponseWriter, multiValue bool) (r *events.APIGatewayProxyResponse, err error) {
+	var flushErr error
+	if w.buffered {
+		flushErr = w.FlushError()
+	}
+	status := w.status
+	if flushErr != nil {
+		log.FromContext(w.ctx).Error("aws_lambda: post buffered websocket message",
+			"connectionId", w.req.RequestContext.ConnectionID, "error", flushErr)
+		status = http.StatusInternalServerError
+	}
+
+	foldTrailers(w.headers)
 	r = &events.APIGatewayProxyResponse{
-		StatusCode:      w.status,
-		IsBase64Encoded: utils.IsBinaryContent(w.Header()),
+		StatusCode:      status,
+		IsBase64Encoded: w.isBinary(),
 	}
 
 	if multiValue {
@@ -1,8 +1,17 @@
 package aws
 
 import (
+	"context"
+	"errors"
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi/types"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/apigatewaymanagementapi"
 	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 )
 
@@ -82,3 +91,128 @@ func TestDefaultRouteSelector(t *testing.T) {
 		assert.Equal(t, route, c.route)
 	}
 }
+
+func TestGetWebsocketConnection(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		conn, ok := GetWebsocketConnection(r.Context())
+		if !assert.True(t, ok) {
+			return
+		}
+		assert.Equal(t, "conn-id", conn.ID)
+		info, err := conn.Info(r.Context())
+		assert.NoError(t, err)
+		assert.Equal(t, "192.0.2.1", info.SourceIP)
+		assert.NoError(t, conn.Post(r.Context(), []byte("bye")))
+		assert.NoError(t, conn.Close(r.Context()))
+	})
+
+	client := &recordingManagementAPI{}
+	h := NewLambdaHandler(mux)
+	h.apiGW = client
+
+	e, err := awstest.NewWebsocketRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)),
+		awstest.WithConnectionID("conn-id"))
+	assert.NoError(t, err)
+	_, err = h.InvokeWebsocketAPI(context.Background(), e)
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"bye"}, client.messages)
+	assert.Equal(t, []string{"conn-id"}, client.deleted)
+}
+
+func TestConnectionGoneError(t *testing.T) {
+	err := v1Error(awserr.New(apigatewaymanagementapi.ErrCodeGoneException, "gone", nil))
+	assert.ErrorIs(t, err, ErrConnectionGone)
+	assert.NotErrorIs(t, v1Error(awserr.New("ForbiddenException", "forbidden", nil)), ErrConnectionGone)
+
+	err = v2Error(&types.GoneException{})
+	assert.ErrorIs(t, err, ErrConnectionGone)
+	assert.NoError(t, v2Error(nil))
+	assert.NotErrorIs(t, v2Error(errors.New("other")), ErrConnectionGone)
+}
+
+func TestLambdaHandler_WebsocketRouteResponse(t *testing.T) {
+	client := &recordingManagementAPI{}
+	h := NewLambdaHandlerWithOption(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a"))
+		w.Write([]byte("b"))
+	}), []interface{}{
+		WithAPIGatewayManagementAPI(client),
+		WithWebsocketResponse(WebsocketPostToConnectionResponse),
+		WithWebsocketRouteResponse("sync", WebsocketReturnResponse),
+		WithWebsocketRouteResponse("batch", WebsocketBufferedResponse),
+		WithWebsocketRouteResponse("$connect", WebsocketPostToConnectionResponse),
+	})
+
+	invoke := func(routeKey string) (string, []string) {
+		client.messages = nil
+		e, err := awstest.NewWebsocketRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)),
+			awstest.WithRouteKey(routeKey))
+		assert.NoError(t, err)
+		res, err := h.InvokeWebsocketAPI(context.Background(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		return res.Body, client.messages
+	}
+
+	body, messages := invoke("sync")
+	assert.Equal(t, "ab", body)
+	assert.Empty(t, messages)
+
+	body, messages = invoke("$default")
+	assert.Empty(t, body)
+	assert.Equal(t, []string{"a", "b"}, messages)
+
+	body, messages = invoke("batch")
+	assert.Empty(t, body)
+	assert.Equal(t, []string{"ab"}, messages)
+
+	// $connect always returns the response
+	body, messages = invoke("$connect")
+	assert.Equal(t, "ab", body)
+	assert.Empty(t, messages)
+}
+
+func TestParseWebsocketResponseType(t *testing.T) {
+	assert.Equal(t, WebsocketReturnResponse, ParseWebsocketResponseType("return"))
+	assert.Equal(t, WebsocketBufferedResponse, ParseWebsocketResponseType("buffered"))
+	assert.Equal(t, WebsocketPostToConnectionResponse, ParseWebsocketResponseType("post_to_connection"))
+	assert.Equal(t, WebsocketPostToConnectionResponse, ParseWebsocketResponseType(""))
+}
+
+func TestLambdaHandler_WebsocketResponseMode(t *testing.T) {
+	client := &recordingManagementAPI{}
+	h := NewLambdaHandlerWithOption(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("a"))
+		w.Write([]byte("b"))
+	}), []interface{}{
+		WithAPIGatewayManagementAPI(client),
+	})
+
+	defer func(mode string) { WebsocketResponseMode = mode }(WebsocketResponseMode)
+
+	invoke := func(mode string) (string, []string) {
+		// the mode is read at the invocation, not when the handler is created
+		WebsocketResponseMode = mode
+		client.messages = nil
+		e, err := awstest.NewWebsocketRequest(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{}`)))
+		assert.NoError(t, err)
+		res, err := h.InvokeWebsocketAPI(context.Background(), e)
+		assert.NoError(t, err)
+		return res.Body, client.messages
+	}
+
+	body, messages := invoke("return")
+	assert.Equal(t, "ab", body)
+	assert.Empty(t, messages)
+
+	body, messages = invoke("buffered")
+	assert.Empty(t, body)
+	assert.Equal(t, []string{"ab"}, messages)
+
+	// unknown values fall back to post_to_connection
+	body, messages = invoke("Return")
+	assert.Empty(t, body)
+	assert.Equal(t, []string{"a", "b"}, messages)
+}
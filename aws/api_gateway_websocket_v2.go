@@ -10,8 +10,11 @@ package aws
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi"
+	"github.com/aws/aws-sdk-go-v2/service/apigatewaymanagementapi/types"
 	"net/url"
 )
 
@@ -24,7 +27,40 @@ func (v v2api) PostToConnection(ctx context.Context, connectionID string, data [
 		ConnectionId: aws.String(connectionID),
 		Data:         data,
 	})
-	return
+	return v2Error(err)
+}
+
+func (v v2api) DeleteConnection(ctx context.Context, connectionID string) (err error) {
+	_, err = v.Client.DeleteConnection(ctx, &apigatewaymanagementapi.DeleteConnectionInput{
+		ConnectionId: aws.String(connectionID),
+	})
+	return v2Error(err)
+}
+
+func (v v2api) GetConnection(ctx context.Context, connectionID string) (*ConnectionInfo, error) {
+	out, err := v.Client.GetConnection(ctx, &apigatewaymanagementapi.GetConnectionInput{
+		ConnectionId: aws.String(connectionID),
+	})
+	if err != nil {
+		return nil, v2Error(err)
+	}
+	info := &ConnectionInfo{
+		ConnectedAt:  aws.ToTime(out.ConnectedAt),
+		LastActiveAt: aws.ToTime(out.LastActiveAt),
+	}
+	if out.Identity != nil {
+		info.SourceIP = aws.ToString(out.Identity.SourceIp)
+		info.UserAgent = aws.ToString(out.Identity.UserAgent)
+	}
+	return info, nil
+}
+
+func v2Error(err error) error {
+	var gone *types.GoneException
+	if errors.As(err, &gone) {
+		return fmt.Errorf("%w: %w", ErrConnectionGone, err)
+	}
+	return err
 }
 
 // NewAPIGatewayManagementClientV2 creates a new API Gateway Management Client instance from the provided parameters. The
@@ -0,0 +1,277 @@
+package awstest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+)
+
+const dynamoDBTargetPrefix = "DynamoDB_20120810."
+
+// DynamoDBServer In-process fake of DynamoDB.
+// It supports PutItem, GetItem, DeleteItem and Query with the equality condition of the partition key,
+// on the tables created by CreateTable. The key attributes must be strings, and the signatures are not verified.
+//
+//	s := awstest.NewDynamoDBServer()
+//	defer s.Close()
+//	s.CreateTable("connections", "pk", "sk")
+//	client := dynamodb.NewFromConfig(conf, func(o *dynamodb.Options) {
+//		o.BaseEndpoint = aws.String(s.URL())
+//	})
+type DynamoDBServer struct {
+	server *httptest.Server
+
+	mu     sync.Mutex
+	tables map[string]*dynamoDBTable
+	// QueryLimit Maximum number of items of a Query page, to test the pagination. 0 means no limit.
+	QueryLimit int
+}
+
+type dynamoDBItem map[string]json.RawMessage
+
+type dynamoDBTable struct {
+	partitionKey string
+	sortKey      string
+	// items Items by the partition key and the sort key.
+	items map[string]map[string]dynamoDBItem
+}
+
+// NewDynamoDBServer starts a new DynamoDBServer listening on a loopback address.
+func NewDynamoDBServer() *DynamoDBServer {
+	s := &DynamoDBServer{
+		tables: map[string]*dynamoDBTable{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the endpoint of the server.
+func (s *DynamoDBServer) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the server.
+func (s *DynamoDBServer) Close() {
+	s.server.Close()
+}
+
+// CreateTable creates the table with the string partition key and the optional string sort key.
+func (s *DynamoDBServer) CreateTable(name, partitionKey, sortKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tables[name] = &dynamoDBTable{
+		partitionKey: partitionKey,
+		sortKey:      sortKey,
+		items:        map[string]map[string]dynamoDBItem{},
+	}
+}
+
+// ItemCount returns the number of the items in the table.
+func (s *DynamoDBServer) ItemCount(table string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tables[table]
+	if !ok {
+		return 0
+	}
+	n := 0
+	for _, items := range t.items {
+		n += len(items)
+	}
+	return n
+}
+
+type dynamoDBRequest struct {
+	TableName                 string
+	Item                      dynamoDBItem
+	Key                       dynamoDBItem
+	KeyConditionExpression    string
+	ExpressionAttributeNames  map[string]string
+	ExpressionAttributeValues dynamoDBItem
+	ExclusiveStartKey         dynamoDBItem
+	Limit                     int
+}
+
+type dynamoDBError struct {
+	typ     string
+	message string
+}
+
+func (e *dynamoDBError) Error() string {
+	return e.message
+}
+
+func (s *DynamoDBServer) handle(w http.ResponseWriter, r *http.Request) {
+	operation, ok := strings.CutPrefix(r.Header.Get("X-Amz-Target"), dynamoDBTargetPrefix)
+	if r.Method != http.MethodPost || !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var req dynamoDBRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeDynamoDBError(w, &dynamoDBError{"SerializationException", err.Error()})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	table, ok := s.tables[req.TableName]
+	if !ok {
+		writeDynamoDBError(w, &dynamoDBError{"ResourceNotFoundException", "Requested resource not found"})
+		return
+	}
+
+	var (
+		res any
+		err error
+	)
+	switch operation {
+	case "PutItem":
+		res, err = table.putItem(&req)
+	case "GetItem":
+		res, err = table.getItem(&req)
+	case "DeleteItem":
+		res, err = table.deleteItem(&req)
+	case "Query":
+		res, err = table.query(&req, s.QueryLimit)
+	default:
+		err = &dynamoDBError{"UnknownOperationException", "unsupported operation: " + operation}
+	}
+	if err != nil {
+		writeDynamoDBError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+func writeDynamoDBError(w http.ResponseWriter, err error) {
+	e, ok := err.(*dynamoDBError)
+	if !ok {
+		e = &dynamoDBError{"ValidationException", err.Error()}
+	}
+	w.Header().Set("Content-Type", "application/x-amz-json-1.0")
+	w.WriteHeader(http.StatusBadRequest)
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"__type":  "com.amazonaws.dynamodb.v20120810#" + e.typ,
+		"message": e.message,
+	})
+}
+
+// stringValue Value of the string attribute.
+func stringValue(item dynamoDBItem, name string) (string, error) {
+	var v struct{ S *string }
+	if raw, ok := item[name]; ok && json.Unmarshal(raw, &v) == nil && v.S != nil {
+		return *v.S, nil
+	}
+	return "", &dynamoDBError{"ValidationException", fmt.Sprintf("missing string attribute: %s", name)}
+}
+
+func (t *dynamoDBTable) key(item dynamoDBItem) (pk, sk string, err error) {
+	if pk, err = stringValue(item, t.partitionKey); err != nil {
+		return
+	}
+	if t.sortKey != "" {
+		sk, err = stringValue(item, t.sortKey)
+	}
+	return
+}
+
+func (t *dynamoDBTable) putItem(req *dynamoDBRequest) (any, error) {
+	pk, sk, err := t.key(req.Item)
+	if err != nil {
+		return nil, err
+	}
+	if t.items[pk] == nil {
+		t.items[pk] = map[string]dynamoDBItem{}
+	}
+	t.items[pk][sk] = req.Item
+	return struct{}{}, nil
+}
+
+func (t *dynamoDBTable) getItem(req *dynamoDBRequest) (any, error) {
+	pk, sk, err := t.key(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	if item, ok := t.items[pk][sk]; ok {
+		return map[string]any{"Item": item}, nil
+	}
+	return struct{}{}, nil
+}
+
+func (t *dynamoDBTable) deleteItem(req *dynamoDBRequest) (any, error) {
+	pk, sk, err := t.key(req.Key)
+	if err != nil {
+		return nil, err
+	}
+	delete(t.items[pk], sk)
+	if len(t.items[pk]) == 0 {
+		delete(t.items, pk)
+	}
+	return struct{}{}, nil
+}
+
+func (t *dynamoDBTable) query(req *dynamoDBRequest, limit int) (any, error) {
+	// Only '{name} = {value}' of the partition key is supported.
+	name, value, ok := strings.Cut(req.KeyConditionExpression, "=")
+	name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+	if n, exists := req.ExpressionAttributeNames[name]; exists {
+		name = n
+	}
+	if !ok || name != t.partitionKey {
+		return nil, &dynamoDBError{"ValidationException", "unsupported key condition: " + req.KeyConditionExpression}
+	}
+	pk, err := stringValue(req.ExpressionAttributeValues, value)
+	if err != nil {
+		return nil, err
+	}
+
+	items := t.items[pk]
+	sks := make([]string, 0, len(items))
+	for sk := range items {
+		sks = append(sks, sk)
+	}
+	sort.Strings(sks)
+
+	if req.ExclusiveStartKey != nil {
+		_, start, err := t.key(req.ExclusiveStartKey)
+		if err != nil {
+			return nil, err
+		}
+		i := sort.SearchStrings(sks, start)
+		if i < len(sks) && sks[i] == start {
+			i++
+		}
+		sks = sks[i:]
+	}
+	if 0 < req.Limit && (limit == 0 || req.Limit < limit) {
+		limit = req.Limit
+	}
+
+	res := map[string]any{}
+	if 0 < limit && limit < len(sks) {
+		sks = sks[:limit]
+		last := items[sks[len(sks)-1]]
+		lastKey := dynamoDBItem{t.partitionKey: last[t.partitionKey]}
+		if t.sortKey != "" {
+			lastKey[t.sortKey] = last[t.sortKey]
+		}
+		res["LastEvaluatedKey"] = lastKey
+	}
+
+	page := make([]dynamoDBItem, 0, len(sks))
+	for _, sk := range sks {
+		page = append(page, items[sk])
+	}
+	res["Items"] = page
+	res["Count"] = len(page)
+	return res, nil
+}
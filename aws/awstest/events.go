@@ -0,0 +1,90 @@
+package awstest
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/internal/awsevents"
+	"net/http"
+)
+
+const (
+	DefaultStage        = awsevents.DefaultStage
+	DefaultResourcePath = awsevents.DefaultResourcePath
+)
+
+// EventOption Option of the event builders.
+type EventOption = awsevents.EventOption
+
+// WithMultiValue Use multi-value headers and query strings for REST API, ALB and WebSocket API events.
+// The default is true.
+func WithMultiValue(multiValue bool) EventOption {
+	return awsevents.WithMultiValue(multiValue)
+}
+
+// WithBase64Body Force the body to be base64 encoded or not.
+// By default, the body is base64 encoded unless the Content-Type is text.
+func WithBase64Body(encode bool) EventOption {
+	return awsevents.WithBase64Body(encode)
+}
+
+// WithStage Set the stage name. The default is DefaultStage for REST API and WebSocket API, and '$default' for HTTP API.
+// For HTTP API, the path is prefixed with the stage name unless the stage is '$default'.
+func WithStage(stage string) EventOption {
+	return awsevents.WithStage(stage)
+}
+
+// WithAuthorizerClaims Set the claims resolved by the authorizer.
+func WithAuthorizerClaims(claims map[string]interface{}) EventOption {
+	return awsevents.WithAuthorizerClaims(claims)
+}
+
+// WithRequestID Set the request id. By default, a random id is generated.
+func WithRequestID(id string) EventOption {
+	return awsevents.WithRequestID(id)
+}
+
+// WithSourceIP Set the source IP. By default, the host part of http.Request.RemoteAddr is used.
+func WithSourceIP(ip string) EventOption {
+	return awsevents.WithSourceIP(ip)
+}
+
+// WithResource Set the resource path and path parameters of REST API event.
+// By default, the resource is DefaultResourcePath and the path is set to the 'proxy' parameter.
+func WithResource(resource string, pathParameters map[string]string) EventOption {
+	return awsevents.WithResource(resource, pathParameters)
+}
+
+// WithRouteKey Set the route key of HTTP API and WebSocket API events.
+func WithRouteKey(routeKey string) EventOption {
+	return awsevents.WithRouteKey(routeKey)
+}
+
+// WithConnectionID Set the connection id of WebSocket API event. By default, a random id is generated.
+func WithConnectionID(id string) EventOption {
+	return awsevents.WithConnectionID(id)
+}
+
+// NewRESTAPIRequest Build API Gateway REST API event from http.Request.
+func NewRESTAPIRequest(r *http.Request, opts ...EventOption) (*events.APIGatewayProxyRequest, error) {
+	return awsevents.NewRESTAPIRequest(r, opts...)
+}
+
+// NewHTTPAPIRequest Build API Gateway HTTP API (payload format version 2.0) event from http.Request.
+func NewHTTPAPIRequest(r *http.Request, opts ...EventOption) (*events.APIGatewayV2HTTPRequest, error) {
+	return awsevents.NewHTTPAPIRequest(r, opts...)
+}
+
+// NewFunctionURLRequest Build Lambda Function URL event from http.Request.
+func NewFunctionURLRequest(r *http.Request, opts ...EventOption) (*events.LambdaFunctionURLRequest, error) {
+	return awsevents.NewFunctionURLRequest(r, opts...)
+}
+
+// NewALBTargetGroupRequest Build Application Load Balancer target group event from http.Request.
+func NewALBTargetGroupRequest(r *http.Request, opts ...EventOption) (*events.ALBTargetGroupRequest, error) {
+	return awsevents.NewALBTargetGroupRequest(r, opts...)
+}
+
+// NewWebsocketRequest Build API Gateway WebSocket API event from http.Request.
+// The event type is derived from the route key, '$connect', '$disconnect' or others.
+func NewWebsocketRequest(r *http.Request, opts ...EventOption) (*events.APIGatewayWebsocketProxyRequest, error) {
+	return awsevents.NewWebsocketRequest(r, opts...)
+}
@@ -0,0 +1,42 @@
+package awstest
+
+import (
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/internal/awsevents"
+	"io"
+	"net/http"
+)
+
+// DecodeResponse Decode the response of the adaptor into http.Response.
+// Supported types are the response structs of REST API, HTTP API, ALB, WebSocket API and Function URLs,
+// and io.Reader of the Function URL streaming response.
+func DecodeResponse(v interface{}) (*http.Response, error) {
+	return awsevents.DecodeResponse(v)
+}
+
+// DecodeRESTAPIResponse Decode API Gateway REST API and WebSocket API response into http.Response.
+func DecodeRESTAPIResponse(res *events.APIGatewayProxyResponse) (*http.Response, error) {
+	return awsevents.DecodeRESTAPIResponse(res)
+}
+
+// DecodeHTTPAPIResponse Decode API Gateway HTTP API response into http.Response.
+func DecodeHTTPAPIResponse(res *events.APIGatewayV2HTTPResponse) (*http.Response, error) {
+	return awsevents.DecodeHTTPAPIResponse(res)
+}
+
+// DecodeALBTargetGroupResponse Decode Application Load Balancer response into http.Response.
+func DecodeALBTargetGroupResponse(res *events.ALBTargetGroupResponse) (*http.Response, error) {
+	return awsevents.DecodeALBTargetGroupResponse(res)
+}
+
+// DecodeFunctionURLResponse Decode Lambda Function URL buffered response into http.Response.
+func DecodeFunctionURLResponse(res *events.LambdaFunctionURLResponse) (*http.Response, error) {
+	return awsevents.DecodeFunctionURLResponse(res)
+}
+
+// DecodeFunctionURLStreamingResponse Decode Lambda Function URL streaming response into http.Response.
+// The reader must start with the JSON prelude followed by 8 NUL bytes, as sent to the Runtime API.
+// The body of the returned response reads the rest of the stream.
+func DecodeFunctionURLStreamingResponse(r io.Reader) (*http.Response, error) {
+	return awsevents.DecodeFunctionURLStreamingResponse(r)
+}
@@ -0,0 +1,309 @@
+/*
+Package awstest provides utilities for testing applications running on AWS Lambda without AWS.
+
+Runtime is an in-process fake of the Lambda Runtime API, so the actual runtime loop started by
+LambdaAdaptor.ListenAndServe can be exercised end-to-end under go test.
+
+	rt := awstest.NewRuntime()
+	defer rt.Close()
+	t.Setenv("AWS_LAMBDA_RUNTIME_API", rt.Address())
+
+	go adaptor.ListenAndServe("", handler)
+
+	res, err := rt.Invoke(ctx, payload)
+
+The New*Request builders convert http.Request into the event of each integration,
+and the Decode*Response functions convert the responses back into http.Response.
+*/
+package awstest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	RuntimeAPIEnvKey = "AWS_LAMBDA_RUNTIME_API"
+
+	headerAWSRequestID       = "Lambda-Runtime-Aws-Request-Id"
+	headerDeadlineMS         = "Lambda-Runtime-Deadline-Ms"
+	headerTraceID            = "Lambda-Runtime-Trace-Id"
+	headerInvokedFunctionARN = "Lambda-Runtime-Invoked-Function-Arn"
+	headerErrorType          = "Lambda-Runtime-Function-Error-Type"
+	trailerErrorBody         = "Lambda-Runtime-Function-Error-Body"
+
+	headerExtensionName       = "Lambda-Extension-Name"
+	headerExtensionIdentifier = "Lambda-Extension-Identifier"
+
+	runtimePathPrefix   = "/2018-06-01/runtime/"
+	extensionPathPrefix = "/2020-01-01/extension/"
+)
+
+const (
+	DefaultFunctionARN = "arn:aws:lambda:us-east-1:123456789012:function:test"
+	DefaultTimeout     = 30 * time.Second
+)
+
+// Result Response or error posted back by the function.
+type Result struct {
+	RequestID   string
+	ContentType string
+	Payload     []byte
+	Trailer     http.Header
+	// Error is set when the function reported an error.
+	Error *FunctionError
+}
+
+// FunctionError Error reported by the function through the Runtime API.
+type FunctionError struct {
+	Type    string `json:"errorType"`
+	Message string `json:"errorMessage"`
+}
+
+func (e *FunctionError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Type, e.Message)
+}
+
+// Invocation Options of a single invocation.
+type Invocation struct {
+	Payload     []byte
+	RequestID   string
+	TraceID     string
+	FunctionARN string
+	Deadline    time.Time
+}
+
+type pendingInvocation struct {
+	*Invocation
+	result chan *Result
+}
+
+// Runtime In-process fake of the Lambda Runtime API.
+type Runtime struct {
+	// FunctionARN is delivered as the invoked function ARN. The default is DefaultFunctionARN.
+	FunctionARN string
+	// Timeout is used to compute the deadline of invocations. The default is DefaultTimeout.
+	Timeout time.Duration
+
+	listener net.Listener
+	server   *http.Server
+	queue    chan *pendingInvocation
+
+	mu         sync.Mutex
+	inflight   map[string]*pendingInvocation
+	extensions []string
+}
+
+// NewRuntime starts a new Runtime listening on a loopback address.
+func NewRuntime() *Runtime {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("awstest: failed to listen on a port: %v", err))
+	}
+
+	r := &Runtime{
+		FunctionARN: DefaultFunctionARN,
+		Timeout:     DefaultTimeout,
+		listener:    l,
+		queue:       make(chan *pendingInvocation),
+		inflight:    map[string]*pendingInvocation{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc(runtimePathPrefix+"invocation/next", r.handleNext)
+	mux.HandleFunc(runtimePathPrefix+"invocation/", r.handleResult)
+	mux.HandleFunc(runtimePathPrefix+"init/error", r.handleInitError)
+	mux.HandleFunc(extensionPathPrefix+"register", r.handleExtensionRegister)
+	mux.HandleFunc(extensionPathPrefix+"event/next", r.handleExtensionNext)
+	r.server = &http.Server{Handler: mux}
+
+	go func() {
+		_ = r.server.Serve(l)
+	}()
+
+	return r
+}
+
+// Address returns the value for AWS_LAMBDA_RUNTIME_API environment variable.
+func (r *Runtime) Address() string {
+	return r.listener.Addr().String()
+}
+
+// Close stops accepting new connections.
+// The runtime loop of the function keeps waiting for the next invocation, since the Lambda runtime
+// terminates the process when the Runtime API is not reachable.
+func (r *Runtime) Close() {
+	_ = r.listener.Close()
+}
+
+// Invoke delivers the payload to the function and waits for the result.
+func (r *Runtime) Invoke(ctx context.Context, payload []byte) (*Result, error) {
+	return r.InvokeWithOptions(ctx, &Invocation{Payload: payload})
+}
+
+// InvokeWithOptions delivers the invocation to the function and waits for the result.
+// Empty fields of the invocation are filled with generated values.
+func (r *Runtime) InvokeWithOptions(ctx context.Context, inv *Invocation) (*Result, error) {
+	if inv.RequestID == "" {
+		inv.RequestID = newID(16)
+	}
+	if inv.TraceID == "" {
+		inv.TraceID = NewTraceID()
+	}
+	if inv.FunctionARN == "" {
+		inv.FunctionARN = r.FunctionARN
+	}
+	if inv.Deadline.IsZero() {
+		inv.Deadline = time.Now().Add(r.Timeout)
+	}
+
+	p := &pendingInvocation{
+		Invocation: inv,
+		result:     make(chan *Result, 1),
+	}
+
+	select {
+	case r.queue <- p:
+	case <-ctx.Done():
+		return nil, fmt.Errorf("awstest: waiting for the runtime to receive the invocation: %w", ctx.Err())
+	}
+
+	select {
+	case res := <-p.result:
+		return res, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("awstest: waiting for the result of the invocation: %w", ctx.Err())
+	}
+}
+
+func (r *Runtime) handleNext(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var p *pendingInvocation
+	select {
+	case p = <-r.queue:
+	case <-req.Context().Done():
+		return
+	}
+
+	r.mu.Lock()
+	r.inflight[p.RequestID] = p
+	r.mu.Unlock()
+
+	w.Header().Set(headerAWSRequestID, p.RequestID)
+	w.Header().Set(headerDeadlineMS, strconv.FormatInt(p.Deadline.UnixMilli(), 10))
+	w.Header().Set(headerTraceID, p.TraceID)
+	w.Header().Set(headerInvokedFunctionARN, p.FunctionARN)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(p.Payload)
+}
+
+// handleResult handles /runtime/invocation/{id}/response and /runtime/invocation/{id}/error.
+func (r *Runtime) handleResult(w http.ResponseWriter, req *http.Request) {
+	rest := strings.TrimPrefix(req.URL.Path, runtimePathPrefix+"invocation/")
+	id, kind, ok := strings.Cut(rest, "/")
+	if !ok || req.Method != http.MethodPost || (kind != "response" && kind != "error") {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	r.mu.Lock()
+	p, ok := r.inflight[id]
+	delete(r.inflight, id)
+	r.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	res := &Result{
+		RequestID:   id,
+		ContentType: req.Header.Get("Content-Type"),
+		Payload:     body,
+		Trailer:     req.Trailer,
+	}
+
+	if kind == "error" {
+		res.Error = &FunctionError{}
+		if err := json.Unmarshal(body, res.Error); err != nil {
+			res.Error.Message = string(body)
+		}
+		if res.Error.Type == "" {
+			res.Error.Type = req.Header.Get(headerErrorType)
+		}
+	} else if errType := req.Trailer.Get(headerErrorType); errType != "" {
+		// Streaming response reports errors with trailers.
+		res.Error = &FunctionError{Type: errType, Message: req.Trailer.Get(trailerErrorBody)}
+	}
+
+	p.result <- res
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (r *Runtime) handleInitError(w http.ResponseWriter, req *http.Request) {
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// Extensions returns the names of the extensions registered through the Extensions API.
+func (r *Runtime) Extensions() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.extensions...)
+}
+
+func (r *Runtime) handleExtensionRegister(w http.ResponseWriter, req *http.Request) {
+	name := req.Header.Get(headerExtensionName)
+	if req.Method != http.MethodPost || name == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	r.mu.Lock()
+	r.extensions = append(r.extensions, name)
+	r.mu.Unlock()
+
+	w.Header().Set(headerExtensionIdentifier, newID(16))
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"functionName":    r.FunctionARN[strings.LastIndex(r.FunctionARN, ":")+1:],
+		"functionVersion": "$LATEST",
+		"handler":         "bootstrap",
+	})
+}
+
+// handleExtensionNext Lifecycle events are not delivered to extensions, so the request blocks until the runtime is closed.
+func (r *Runtime) handleExtensionNext(w http.ResponseWriter, req *http.Request) {
+	<-req.Context().Done()
+}
+
+// NewTraceID generates a new X-Ray trace header value.
+func NewTraceID() string {
+	return fmt.Sprintf("Root=1-%08x-%s;Parent=%s;Sampled=1", time.Now().Unix(), newID(12), newID(8))
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
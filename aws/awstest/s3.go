@@ -0,0 +1,108 @@
+package awstest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+)
+
+// S3Server In-process fake of S3 compatible storage.
+// It supports PutObject and GetObject with path-style requests, and the signatures are not verified.
+//
+//	s := awstest.NewS3Server()
+//	defer s.Close()
+//	client := s3.NewFromConfig(conf, func(o *s3.Options) {
+//		o.BaseEndpoint = aws.String(s.URL())
+//		o.UsePathStyle = true
+//	})
+type S3Server struct {
+	server *httptest.Server
+
+	mu      sync.Mutex
+	objects map[string]*s3Object
+}
+
+type s3Object struct {
+	contentType     string
+	contentEncoding string
+	body            []byte
+}
+
+// NewS3Server starts a new S3Server listening on a loopback address.
+func NewS3Server() *S3Server {
+	s := &S3Server{
+		objects: map[string]*s3Object{},
+	}
+	s.server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// URL returns the endpoint of the server.
+func (s *S3Server) URL() string {
+	return s.server.URL
+}
+
+// Close shuts down the server.
+func (s *S3Server) Close() {
+	s.server.Close()
+}
+
+// Object returns the body of the stored object.
+func (s *S3Server) Object(bucket, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	o, ok := s.objects[bucket+"/"+key]
+	if !ok {
+		return nil, false
+	}
+	return o.body, true
+}
+
+func (s *S3Server) handle(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	if bucket, key, ok := strings.Cut(name, "/"); !ok || bucket == "" || key == "" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.objects[name] = &s3Object{
+			contentType:     r.Header.Get("Content-Type"),
+			contentEncoding: r.Header.Get("Content-Encoding"),
+			body:            body,
+		}
+		s.mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet, http.MethodHead:
+		s.mu.Lock()
+		o, ok := s.objects[name]
+		s.mu.Unlock()
+		if !ok {
+			w.Header().Set("Content-Type", "application/xml")
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = io.WriteString(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>NoSuchKey</Code></Error>`)
+			return
+		}
+		if o.contentType != "" {
+			w.Header().Set("Content-Type", o.contentType)
+		}
+		if o.contentEncoding != "" {
+			w.Header().Set("Content-Encoding", o.contentEncoding)
+		}
+		w.WriteHeader(http.StatusOK)
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(o.body)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
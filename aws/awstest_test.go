@@ -0,0 +1,168 @@
+package aws
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAWSTest_RoundTrip(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+
+		rc, ok := GetRequestContext(r.Context())
+		assert.True(t, ok)
+		w.Header().Set("X-Request-Id", rc.RequestID())
+		w.Header().Set("X-Stage", rc.Stage())
+		if sub, ok := rc.AuthorizerClaims()["sub"]; ok {
+			w.Header().Set("X-Sub", sub.(string))
+		}
+		w.Header().Set("X-Query", r.URL.Query().Get("q"))
+		w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "1"})
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	})
+
+	h := NewLambdaHandlerWithOption(mux, nil)
+	ctx := context.Background()
+
+	opts := []awstest.EventOption{
+		awstest.WithRequestID("request-id"),
+		awstest.WithStage("dev"),
+		awstest.WithAuthorizerClaims(map[string]interface{}{"sub": "user"}),
+	}
+
+	cases := []struct {
+		name   string
+		invoke func(r *http.Request) (*http.Response, error)
+		stage  string
+		sub    string
+	}{
+		{
+			name: "rest api",
+			invoke: func(r *http.Request) (*http.Response, error) {
+				e, err := awstest.NewRESTAPIRequest(r, opts...)
+				if err != nil {
+					return nil, err
+				}
+				res, err := h.InvokeRESTAPI(ctx, e)
+				if err != nil {
+					return nil, err
+				}
+				return awstest.DecodeResponse(res)
+			},
+			stage: "dev",
+			sub:   "user",
+		},
+		{
+			name: "rest api single value",
+			invoke: func(r *http.Request) (*http.Response, error) {
+				e, err := awstest.NewRESTAPIRequest(r, append(opts, awstest.WithMultiValue(false))...)
+				if err != nil {
+					return nil, err
+				}
+				res, err := h.InvokeRESTAPI(ctx, e)
+				if err != nil {
+					return nil, err
+				}
+				return awstest.DecodeResponse(res)
+			},
+			stage: "dev",
+			sub:   "user",
+		},
+		{
+			name: "http api",
+			invoke: func(r *http.Request) (*http.Response, error) {
+				// named stages are included in the raw path of HTTP API
+				e, err := awstest.NewHTTPAPIRequest(r, append(opts, awstest.WithStage("$default"))...)
+				if err != nil {
+					return nil, err
+				}
+				res, err := h.InvokeHTTPAPI(ctx, e)
+				if err != nil {
+					return nil, err
+				}
+				return awstest.DecodeResponse(res)
+			},
+			stage: "$default",
+			sub:   "user",
+		},
+		{
+			name: "alb target group",
+			invoke: func(r *http.Request) (*http.Response, error) {
+				e, err := awstest.NewALBTargetGroupRequest(r, opts...)
+				if err != nil {
+					return nil, err
+				}
+				res, err := h.InvokeALBTargetGroup(ctx, e)
+				if err != nil {
+					return nil, err
+				}
+				return awstest.DecodeResponse(res)
+			},
+		},
+		{
+			name: "function url",
+			invoke: func(r *http.Request) (*http.Response, error) {
+				e, err := awstest.NewFunctionURLRequest(r, opts...)
+				if err != nil {
+					return nil, err
+				}
+				res, err := h.InvokeFunctionURLStream(ctx, e)
+				if err != nil {
+					return nil, err
+				}
+				return awstest.DecodeResponse(res)
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/echo?q=1", strings.NewReader("\x00\x01binary"))
+			r.Header.Set("Content-Type", "application/octet-stream")
+
+			res, err := c.invoke(r)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			assert.Equal(t, "1", res.Header.Get("X-Query"))
+			assert.Equal(t, c.stage, res.Header.Get("X-Stage"))
+			assert.Equal(t, c.sub, res.Header.Get("X-Sub"))
+			assert.Equal(t, "session=1", res.Header.Get("Set-Cookie"))
+			assert.Equal(t, "application/octet-stream", res.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, "\x00\x01binary", string(body))
+		})
+	}
+}
+
+func TestAWSTest_NewWebsocketRequest(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/?token=abc", strings.NewReader(`{"action":"send"}`))
+	r.Header.Set("Content-Type", "application/json")
+
+	connect, err := awstest.NewWebsocketRequest(r, awstest.WithRouteKey("$connect"), awstest.WithConnectionID("conn"))
+	assert.NoError(t, err)
+	assert.Equal(t, "CONNECT", connect.RequestContext.EventType)
+	assert.Equal(t, "conn", connect.RequestContext.ConnectionID)
+	assert.Equal(t, []string{"abc"}, connect.MultiValueQueryStringParameters["token"])
+	assert.Empty(t, connect.Body)
+
+	message, err := awstest.NewWebsocketRequest(r, awstest.WithRouteKey("send"))
+	assert.NoError(t, err)
+	assert.Equal(t, "MESSAGE", message.RequestContext.EventType)
+	assert.Equal(t, `{"action":"send"}`, message.Body)
+	assert.False(t, message.IsBase64Encoded)
+	assert.NotEmpty(t, message.RequestContext.ConnectionID)
+}
@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DefaultCompressionMinSize Responses smaller than this are not compressed.
+const DefaultCompressionMinSize = 1024
+
+// EncoderFunc Create a writer compressing into w, such as gzip.NewWriter.
+// The content encoding of brotli can be plugged in with 'github.com/andybalholm/brotli':
+//
+//	aws.WithCompressionEncoder("br", func(w io.Writer) (io.WriteCloser, error) {
+//		return brotli.NewWriter(w), nil
+//	})
+type EncoderFunc func(w io.Writer) (io.WriteCloser, error)
+
+type contentEncoder struct {
+	encoding string
+	encoder  EncoderFunc
+}
+
+type compression struct {
+	enabled bool
+	// encoders Ordered by the server preference, used when the client accepts several encodings with the same q-value.
+	encoders  []contentEncoder
+	minSize   int
+	mimeTypes []string
+}
+
+func newCompression() compression {
+	return compression{
+		encoders: []contentEncoder{
+			{encoding: "gzip", encoder: func(w io.Writer) (io.WriteCloser, error) {
+				return gzip.NewWriter(w), nil
+			}},
+			{encoding: "deflate", encoder: func(w io.Writer) (io.WriteCloser, error) {
+				return flate.NewWriter(w, flate.DefaultCompression)
+			}},
+		},
+		minSize: DefaultCompressionMinSize,
+	}
+}
+
+// addEncoder Encoders added later are preferred.
+func (c *compression) addEncoder(encoding string, encoder EncoderFunc) {
+	encoders := []contentEncoder{{encoding: encoding, encoder: encoder}}
+	for _, e := range c.encoders {
+		if e.encoding != encoding {
+			encoders = append(encoders, e)
+		}
+	}
+	c.encoders = encoders
+}
+
+// registerEncodings Register the content encodings to the classifier, so that compressed bodies are base64 encoded.
+func (c *compression) registerEncodings(classifier *utils.ContentClassifier) {
+	for _, e := range c.encoders {
+		classifier.RegisterBinaryContentEncoding(e.encoding)
+	}
+}
+
+// compressible reports whether the response can be compressed regardless of the request.
+func (c *compression) compressible(w *ResponseWriter) bool {
+	if w.status != 0 && (w.status < 200 || w.status == http.StatusNoContent || w.status == http.StatusNotModified) {
+		return false
+	}
+	if w.headers.Get(types.HTTPHeaderContentEncoding) != "" {
+		return false
+	}
+	if strings.Contains(w.headers.Get("Cache-Control"), "no-transform") {
+		return false
+	}
+	if w.buf.Len() < c.minSize {
+		return false
+	}
+	return c.allowedMIMEType(w, w.headers.Get(types.HTTPHeaderContentType))
+}
+
+func (c *compression) allowedMIMEType(w *ResponseWriter, contentType string) bool {
+	if c.mimeTypes == nil {
+		if w.classifier != nil {
+			return w.classifier.IsTextContent(contentType)
+		}
+		return utils.IsTextContent(contentType)
+	}
+	m, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, pattern := range c.mimeTypes {
+		if utils.MatchMediaType(pattern, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAcceptEncoding q-values of the encodings in Accept-Encoding, by the lower case name.
+func parseAcceptEncoding(acceptEncoding []string) map[string]float64 {
+	q := map[string]float64{}
+	for _, v := range acceptEncoding {
+		for _, token := range strings.Split(v, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(token), ";")
+			name = strings.ToLower(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			weight := 1.0
+			for _, param := range strings.Split(params, ";") {
+				k, v, ok := strings.Cut(param, "=")
+				if !ok || !strings.EqualFold(strings.TrimSpace(k), "q") {
+					continue
+				}
+				if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+					weight = f
+				}
+				break
+			}
+			q[name] = weight
+		}
+	}
+	return q
+}
+
+// acceptsEncoding reports whether the encoding has the positive q-value, explicitly or with '*'.
+func acceptsEncoding(q map[string]float64, encoding string) bool {
+	weight, ok := q[encoding]
+	if !ok {
+		weight, ok = q["*"]
+	}
+	return ok && 0 < weight
+}
+
+// negotiate Select the encoder with the highest q-value in Accept-Encoding.
+func (c *compression) negotiate(acceptEncoding []string) *contentEncoder {
+	q := parseAcceptEncoding(acceptEncoding)
+
+	var (
+		selected *contentEncoder
+		best     float64
+	)
+	for i, e := range c.encoders {
+		weight, ok := q[e.encoding]
+		if !ok {
+			weight, ok = q["*"]
+		}
+		if ok && best < weight {
+			selected, best = &c.encoders[i], weight
+		}
+	}
+	return selected
+}
+
+// compress Compress the buffered body with the encoding negotiated from Accept-Encoding of the request.
+func (c *compression) compress(req *http.Request, w *ResponseWriter) {
+	if !c.enabled || !c.compressible(w) {
+		return
+	}
+
+	// The response varies by Accept-Encoding even if it is not compressed for this request.
+	addVary(w.headers, "Accept-Encoding")
+
+	e := c.negotiate(req.Header.Values("Accept-Encoding"))
+	if e == nil {
+		return
+	}
+
+	var buf bytes.Buffer
+	ew, err := e.encoder(&buf)
+	if err != nil {
+		return
+	}
+	if _, err := ew.Write(w.buf.Bytes()); err != nil {
+		return
+	}
+	if err := ew.Close(); err != nil {
+		return
+	}
+
+	w.buf = buf
+	w.headers.Set(types.HTTPHeaderContentEncoding, e.encoding)
+	w.headers.Del(types.HTTPHeaderContentLength)
+}
+
+// addVary Add the header name to Vary, unless it is already listed or Vary is '*'.
+func addVary(h http.Header, name string) {
+	for _, v := range h.Values("Vary") {
+		for _, token := range strings.Split(v, ",") {
+			token = strings.TrimSpace(token)
+			if token == "*" || strings.EqualFold(token, name) {
+				return
+			}
+		}
+	}
+	h.Add("Vary", name)
+}
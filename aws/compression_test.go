@@ -0,0 +1,253 @@
+package aws
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type prefixWriter struct {
+	io.Writer
+}
+
+func (p *prefixWriter) Close() error {
+	return nil
+}
+
+func TestLambdaHandler_Compression(t *testing.T) {
+	json := `{"message":"` + strings.Repeat("a", 2048) + `"}`
+	mux := http.NewServeMux()
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(json))
+	})
+	mux.HandleFunc("/small", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+	mux.HandleFunc("/image", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write(make([]byte, 2048))
+	})
+	mux.HandleFunc("/vary", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Vary", "Origin, accept-encoding")
+		w.Write([]byte(json))
+	})
+	mux.HandleFunc("/problem", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/problem+json")
+		w.Write([]byte(json))
+	})
+
+	testEncoder := func(w io.Writer) (io.WriteCloser, error) {
+		_, _ = w.Write([]byte("x-test:"))
+		return &prefixWriter{w}, nil
+	}
+
+	decoders := map[string]func(r io.Reader) io.Reader{
+		"gzip": func(r io.Reader) io.Reader {
+			gz, err := gzip.NewReader(r)
+			assert.NoError(t, err)
+			return gz
+		},
+		"deflate": func(r io.Reader) io.Reader {
+			return flate.NewReader(r)
+		},
+		"x-test": func(r io.Reader) io.Reader {
+			b, _ := io.ReadAll(r)
+			return bytes.NewReader(bytes.TrimPrefix(b, []byte("x-test:")))
+		},
+	}
+
+	cases := []struct {
+		name           string
+		options        []interface{}
+		path           string
+		acceptEncoding string
+		encoding       string
+		vary           bool
+	}{
+		{
+			name:           "disabled",
+			path:           "/json",
+			acceptEncoding: "gzip",
+		},
+		{
+			name:           "gzip",
+			options:        []interface{}{WithCompression()},
+			path:           "/json",
+			acceptEncoding: "gzip, deflate",
+			encoding:       "gzip",
+			vary:           true,
+		},
+		{
+			name:           "q-value",
+			options:        []interface{}{WithCompression()},
+			path:           "/json",
+			acceptEncoding: "gzip;q=0.5, deflate",
+			encoding:       "deflate",
+			vary:           true,
+		},
+		{
+			name:           "q-value after other parameters",
+			options:        []interface{}{WithCompression()},
+			path:           "/json",
+			acceptEncoding: "gzip;foo=1;q=0, deflate",
+			encoding:       "deflate",
+			vary:           true,
+		},
+		{
+			name:           "not acceptable",
+			options:        []interface{}{WithCompression()},
+			path:           "/json",
+			acceptEncoding: "identity",
+			vary:           true,
+		},
+		{
+			name:           "below min size",
+			options:        []interface{}{WithCompression()},
+			path:           "/small",
+			acceptEncoding: "gzip",
+		},
+		{
+			name:           "min size",
+			options:        []interface{}{WithCompression(), WithCompressionMinSize(0)},
+			path:           "/small",
+			acceptEncoding: "gzip",
+			encoding:       "gzip",
+			vary:           true,
+		},
+		{
+			name:           "binary",
+			options:        []interface{}{WithCompression()},
+			path:           "/image",
+			acceptEncoding: "gzip",
+		},
+		{
+			name:           "mime allowlist",
+			options:        []interface{}{WithCompression(), WithCompressionMIMETypes("*/*+json")},
+			path:           "/problem",
+			acceptEncoding: "*",
+			encoding:       "gzip",
+			vary:           true,
+		},
+		{
+			name:           "custom encoder",
+			options:        []interface{}{WithCompressionEncoder("x-test", testEncoder)},
+			path:           "/json",
+			acceptEncoding: "gzip, x-test",
+			encoding:       "x-test",
+			vary:           true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			e := &events.APIGatewayV2HTTPRequest{
+				Version: "2.0",
+				RawPath: c.path,
+				Headers: map[string]string{"accept-encoding": c.acceptEncoding},
+			}
+			e.RequestContext.HTTP.Method = http.MethodGet
+
+			res, err := NewLambdaHandlerWithOption(mux, c.options).InvokeHTTPAPI(context.Background(), e)
+			assert.NoError(t, err)
+			assert.Equal(t, c.encoding, res.Headers["Content-Encoding"])
+			assert.Equal(t, c.vary, res.Headers["Vary"] == "Accept-Encoding")
+
+			if c.encoding == "" {
+				return
+			}
+			assert.True(t, res.IsBase64Encoded)
+			b, err := base64.StdEncoding.DecodeString(res.Body)
+			assert.NoError(t, err)
+			body, err := io.ReadAll(decoders[c.encoding](bytes.NewReader(b)))
+			assert.NoError(t, err)
+			if c.path == "/small" {
+				assert.Equal(t, `{}`, string(body))
+			} else {
+				assert.Equal(t, json, string(body))
+			}
+		})
+	}
+
+	t.Run("vary listed by the handler", func(t *testing.T) {
+		e := &events.APIGatewayV2HTTPRequest{
+			Version: "2.0",
+			RawPath: "/vary",
+			Headers: map[string]string{"accept-encoding": "gzip"},
+		}
+		e.RequestContext.HTTP.Method = http.MethodGet
+
+		res, err := NewLambdaHandlerWithOption(mux, []interface{}{WithCompression()}).InvokeHTTPAPI(context.Background(), e)
+		assert.NoError(t, err)
+		assert.Equal(t, "gzip", res.Headers["Content-Encoding"])
+		assert.Equal(t, "Origin, accept-encoding", res.Headers["Vary"])
+	})
+}
+
+func TestLambdaHandler_ContentClassifier(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+
+	classifier := utils.NewContentClassifier()
+	classifier.AddBinaryMediaTypes("*/*")
+
+	e := &events.APIGatewayProxyRequest{Resource: "/", Path: "/", HTTPMethod: http.MethodGet}
+
+	res, err := NewLambdaHandlerWithOption(h, []interface{}{WithContentClassifier(classifier)}).InvokeRESTAPI(context.Background(), e)
+	assert.NoError(t, err)
+	assert.True(t, res.IsBase64Encoded)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{}`)), res.Body)
+
+	// other handlers use the default classifier
+	res, err = NewLambdaHandler(h).InvokeRESTAPI(context.Background(), e)
+	assert.NoError(t, err)
+	assert.False(t, res.IsBase64Encoded)
+}
+
+func TestLambdaHandler_ContentClassifierWebsocket(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	})
+
+	classifier := utils.NewContentClassifier()
+	classifier.AddBinaryMediaTypes("application/json")
+
+	e := &events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{RouteKey: "$connect", ConnectionID: "id"},
+	}
+	res, err := NewLambdaHandlerWithOption(h, []interface{}{WithContentClassifier(classifier)}).InvokeWebsocketAPI(context.Background(), e)
+	assert.NoError(t, err)
+	assert.True(t, res.IsBase64Encoded)
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(`{}`)), res.Body)
+}
+
+func TestLambdaHandler_CompressionClassifier(t *testing.T) {
+	classifier := utils.NewContentClassifier()
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set("Content-Encoding", "x-test")
+
+	NewLambdaHandlerWithOption(http.NotFoundHandler(), []interface{}{
+		WithContentClassifier(classifier),
+		WithCompressionEncoder("x-test", func(w io.Writer) (io.WriteCloser, error) {
+			return &prefixWriter{w}, nil
+		}),
+	})
+	// the encoding is registered to the copy of the handler
+	assert.False(t, classifier.IsBinaryContent(header))
+	assert.False(t, utils.DefaultContentClassifier.IsBinaryContent(header))
+}
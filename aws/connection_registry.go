@@ -0,0 +1,211 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AllConnections Group that every connection belongs to. Broadcast to it sends the message to all connections.
+const AllConnections = ""
+
+// DefaultBroadcastConcurrency Default number of concurrent PostToConnection calls of Broadcast.
+const DefaultBroadcastConcurrency = 16
+
+// ErrNoManagementAPI Broadcast is called without the client of the API Gateway management API.
+var ErrNoManagementAPI = errors.New("websocket: no client for API Gateway management API")
+
+// Connection WebSocket connection recorded in ConnectionStore.
+type Connection struct {
+	ID string
+	// Groups Groups of the connection, without AllConnections.
+	Groups      []string
+	Tags        map[string]string
+	ConnectedAt time.Time
+}
+
+// ConnectionStore Storage of the connections, shared by the execution environments.
+type ConnectionStore interface {
+	// PutConnection Record the connection to its groups and AllConnections.
+	PutConnection(ctx context.Context, conn *Connection) error
+	// DeleteConnection Remove the connection from all groups. It is not an error if the connection does not exist.
+	DeleteConnection(ctx context.Context, connectionID string) error
+	// ListConnections List the connections of the group.
+	ListConnections(ctx context.Context, group string) ([]*Connection, error)
+}
+
+// ConnectionTagger Decide the groups and tags of the connection from the $connect request.
+// The request has the context of the request, such as GetWebsocketRequestContext for the authorizer.
+type ConnectionTagger func(r *http.Request) (groups []string, tags map[string]string)
+
+type ConnectionRegistryOption func(r *ConnectionRegistry)
+
+// WithConnectionTagger Set the function to decide the groups and tags of the connections.
+// Without it, connections only belong to AllConnections.
+func WithConnectionTagger(tagger ConnectionTagger) ConnectionRegistryOption {
+	return func(r *ConnectionRegistry) {
+		r.tagger = tagger
+	}
+}
+
+// WithBroadcastClient Set the client of the API Gateway management API used by Broadcast.
+// Without it, Broadcast uses the client of the current websocket request, so it must be called from the websocket handlers.
+func WithBroadcastClient(client APIGatewayManagementAPI) ConnectionRegistryOption {
+	return func(r *ConnectionRegistry) {
+		r.client = client
+	}
+}
+
+// WithBroadcastConcurrency Set the number of concurrent PostToConnection calls of Broadcast.
+func WithBroadcastConcurrency(n int) ConnectionRegistryOption {
+	return func(r *ConnectionRegistry) {
+		if 0 < n {
+			r.concurrency = n
+		}
+	}
+}
+
+// ConnectionRegistry Record the connections of API Gateway WebSocket API on $connect and $disconnect routes,
+// and send messages to the groups of the connections.
+type ConnectionRegistry struct {
+	store       ConnectionStore
+	tagger      ConnectionTagger
+	client      APIGatewayManagementAPI
+	concurrency int
+}
+
+// NewConnectionRegistry creates a new ConnectionRegistry recording the connections into the store.
+// It is enabled by WithConnectionRegistry.
+func NewConnectionRegistry(store ConnectionStore, options ...ConnectionRegistryOption) *ConnectionRegistry {
+	r := &ConnectionRegistry{
+		store:       store,
+		concurrency: DefaultBroadcastConcurrency,
+	}
+	for _, opt := range options {
+		opt(r)
+	}
+	return r
+}
+
+// Store Get the store of the connections.
+func (r *ConnectionRegistry) Store() ConnectionStore {
+	return r.store
+}
+
+// connect Record the connection of the accepted $connect request.
+func (r *ConnectionRegistry) connect(req *http.Request, connectionID string) error {
+	conn := &Connection{
+		ID:          connectionID,
+		ConnectedAt: time.Now(),
+	}
+	if rc, ok := GetWebsocketRequestContext(req.Context()); ok && rc.ConnectedAt != 0 {
+		conn.ConnectedAt = time.UnixMilli(rc.ConnectedAt)
+	}
+	if r.tagger != nil {
+		conn.Groups, conn.Tags = r.tagger(req)
+	}
+	return r.store.PutConnection(req.Context(), conn)
+}
+
+// disconnect Remove the connection of the $disconnect request.
+func (r *ConnectionRegistry) disconnect(ctx context.Context, connectionID string) error {
+	return r.store.DeleteConnection(ctx, connectionID)
+}
+
+// Broadcast Send the message to the connections of the group concurrently.
+// Connections which are already gone are removed from the store.
+// The returned error joins the errors of the other connections.
+func (r *ConnectionRegistry) Broadcast(ctx context.Context, group string, data []byte) error {
+	client := r.client
+	if client == nil {
+		conn, ok := GetWebsocketConnection(ctx)
+		if !ok {
+			return ErrNoManagementAPI
+		}
+		var err error
+		if client, err = conn.Client(); err != nil {
+			return err
+		}
+	}
+
+	conns, err := r.store.ListConnections(ctx, group)
+	if err != nil {
+		return fmt.Errorf("websocket: list connections: %w", err)
+	}
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, r.concurrency)
+	)
+	for _, conn := range conns {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			err := client.PostToConnection(ctx, id, data)
+			if errors.Is(err, ErrConnectionGone) {
+				log.FromContext(ctx).Debug("aws_lambda: prune gone connection", "connectionId", id)
+				err = r.store.DeleteConnection(ctx, id)
+			}
+			if err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("websocket: broadcast to %s: %w", id, err))
+				mu.Unlock()
+			}
+		}(conn.ID)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// MemoryConnectionStore ConnectionStore in memory.
+// Since it is not shared by the execution environments, it is for tests and the local emulator.
+type MemoryConnectionStore struct {
+	mu          sync.Mutex
+	connections map[string]*Connection
+}
+
+func NewMemoryConnectionStore() *MemoryConnectionStore {
+	return &MemoryConnectionStore{
+		connections: map[string]*Connection{},
+	}
+}
+
+func (s *MemoryConnectionStore) PutConnection(ctx context.Context, conn *Connection) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.connections[conn.ID] = conn
+	return nil
+}
+
+func (s *MemoryConnectionStore) DeleteConnection(ctx context.Context, connectionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.connections, connectionID)
+	return nil
+}
+
+func (s *MemoryConnectionStore) ListConnections(ctx context.Context, group string) ([]*Connection, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var conns []*Connection
+	for _, conn := range s.connections {
+		if group == AllConnections || slices.Contains(conn.Groups, group) {
+			conns = append(conns, conn)
+		}
+	}
+	sort.Slice(conns, func(i, j int) bool {
+		return conns[i].ID < conns[j].ID
+	})
+	return conns, nil
+}
@@ -0,0 +1,106 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+)
+
+type broadcastManagementAPI struct {
+	recordingManagementAPI
+	mu   sync.Mutex
+	gone map[string]bool
+	sent []string
+}
+
+func (b *broadcastManagementAPI) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	if b.gone[connectionID] {
+		return fmt.Errorf("%w: GoneException", ErrConnectionGone)
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sent = append(b.sent, connectionID+":"+string(data))
+	return nil
+}
+
+func invokeWebsocketRoute(t *testing.T, h *LambdaHandler, routeKey, connectionID, target string) int {
+	e, err := awstest.NewWebsocketRequest(httptest.NewRequest(http.MethodGet, target, nil),
+		awstest.WithRouteKey(routeKey), awstest.WithConnectionID(connectionID))
+	assert.NoError(t, err)
+	res, err := h.InvokeWebsocketAPI(context.Background(), e)
+	assert.NoError(t, err)
+	return res.StatusCode
+}
+
+func TestConnectionRegistry(t *testing.T) {
+	store := NewMemoryConnectionStore()
+	client := &broadcastManagementAPI{gone: map[string]bool{"gone": true}}
+	registry := NewConnectionRegistry(store,
+		WithBroadcastClient(client),
+		WithConnectionTagger(func(r *http.Request) ([]string, map[string]string) {
+			return []string{r.URL.Query().Get("room")}, map[string]string{"user": r.URL.Query().Get("user")}
+		}),
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("user") == "banned" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	h := NewLambdaHandlerWithOption(mux, []interface{}{WithConnectionRegistry(registry)})
+
+	assert.Equal(t, http.StatusOK, invokeWebsocketRoute(t, h, "$connect", "a", "/?room=lobby&user=alice"))
+	assert.Equal(t, http.StatusOK, invokeWebsocketRoute(t, h, "$connect", "b", "/?room=game&user=bob"))
+	assert.Equal(t, http.StatusOK, invokeWebsocketRoute(t, h, "$connect", "gone", "/?room=lobby&user=carol"))
+	assert.Equal(t, http.StatusForbidden, invokeWebsocketRoute(t, h, "$connect", "c", "/?room=lobby&user=banned"))
+
+	conns, err := store.ListConnections(context.Background(), "lobby")
+	assert.NoError(t, err)
+	if assert.Len(t, conns, 2) {
+		assert.Equal(t, "a", conns[0].ID)
+		assert.Equal(t, map[string]string{"user": "alice"}, conns[0].Tags)
+		assert.False(t, conns[0].ConnectedAt.IsZero())
+	}
+
+	// gone connections are pruned
+	assert.NoError(t, registry.Broadcast(context.Background(), "lobby", []byte("hello")))
+	assert.Equal(t, []string{"a:hello"}, client.sent)
+	conns, _ = store.ListConnections(context.Background(), AllConnections)
+	assert.Len(t, conns, 2)
+
+	assert.Equal(t, http.StatusOK, invokeWebsocketRoute(t, h, "$disconnect", "b", "/"))
+	client.sent = nil
+	assert.NoError(t, registry.Broadcast(context.Background(), AllConnections, []byte("bye")))
+	sort.Strings(client.sent)
+	assert.Equal(t, []string{"a:bye"}, client.sent)
+}
+
+func TestConnectionRegistry_BroadcastWithoutClient(t *testing.T) {
+	registry := NewConnectionRegistry(NewMemoryConnectionStore())
+	assert.ErrorIs(t, registry.Broadcast(context.Background(), AllConnections, nil), ErrNoManagementAPI)
+}
+
+type failingConnectionStore struct {
+	MemoryConnectionStore
+}
+
+func (f *failingConnectionStore) PutConnection(ctx context.Context, conn *Connection) error {
+	return fmt.Errorf("unavailable")
+}
+
+func TestConnectionRegistry_RejectUnrecordedConnection(t *testing.T) {
+	registry := NewConnectionRegistry(&failingConnectionStore{})
+	h := NewLambdaHandlerWithOption(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}), []interface{}{WithConnectionRegistry(registry)})
+	assert.Equal(t, http.StatusInternalServerError, invokeWebsocketRoute(t, h, "$connect", "a", "/"))
+}
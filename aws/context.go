@@ -3,9 +3,15 @@ package aws
 import (
 	"context"
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/types"
 	"github.com/yacchi/lambda-http-adaptor/utils"
 )
 
+// GetRequestContext Get integration independent RequestContext value from Context.
+func GetRequestContext(ctx context.Context) (types.RequestContext, bool) {
+	return utils.RequestContextValue(ctx)
+}
+
 func GetRawRequestContext(ctx context.Context) interface{} {
 	rawReq, ok := utils.RawRequestValue(ctx)
 	if !ok {
@@ -20,6 +26,8 @@ func GetRawRequestContext(ctx context.Context) interface{} {
 		return &req.RequestContext
 	case *events.APIGatewayWebsocketProxyRequest:
 		return &req.RequestContext
+	case *events.LambdaFunctionURLRequest:
+		return &req.RequestContext
 	}
 	return nil
 }
@@ -52,6 +60,20 @@ func GetWebsocketRequestContext(ctx context.Context) (req *events.APIGatewayWebs
 	return
 }
 
+func GetFunctionURLRequestContext(ctx context.Context) (req *events.LambdaFunctionURLRequestContext, ok bool) {
+	if o := GetRawRequestContext(ctx); o != nil {
+		req, ok = o.(*events.LambdaFunctionURLRequestContext)
+	}
+	return
+}
+
+// GetWebsocketConnection Get the connection of the current websocket request,
+// to send messages, close the connection or look up its information from the handler.
+func GetWebsocketConnection(ctx context.Context) (*WebsocketConnection, bool) {
+	conn, ok := ctx.Value(websocketConnectionContextKey{}).(*WebsocketConnection)
+	return conn, ok
+}
+
 func GetStageVariables(ctx context.Context) map[string]string {
 	rawReq, ok := utils.RawRequestValue(ctx)
 	if !ok {
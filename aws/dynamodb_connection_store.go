@@ -0,0 +1,197 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"strconv"
+	"time"
+)
+
+// Attributes of the items of DynamoDBConnectionStore.
+const (
+	DynamoDBPartitionKey = "pk"
+	DynamoDBSortKey      = "sk"
+)
+
+const (
+	dynamoDBConnectionPrefix = "connection#"
+	dynamoDBGroupPrefix      = "group#"
+	dynamoDBConnectionSK     = "#connection"
+)
+
+// DynamoDBConnectionAPI Subset of dynamodb.Client used by DynamoDBConnectionStore.
+type DynamoDBConnectionAPI interface {
+	PutItem(ctx context.Context, params *dynamodb.PutItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.PutItemOutput, error)
+	GetItem(ctx context.Context, params *dynamodb.GetItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.GetItemOutput, error)
+	DeleteItem(ctx context.Context, params *dynamodb.DeleteItemInput, optFns ...func(*dynamodb.Options)) (*dynamodb.DeleteItemOutput, error)
+	Query(ctx context.Context, params *dynamodb.QueryInput, optFns ...func(*dynamodb.Options)) (*dynamodb.QueryOutput, error)
+}
+
+// DynamoDBConnectionStore ConnectionStore implementation with a DynamoDB table,
+// which has the string partition key 'pk' and the string sort key 'sk'.
+//
+// A connection is stored as the item of 'connection#{id}' partition,
+// and the items of 'group#{group}' partitions sorted by the connection ID for each group, including AllConnections.
+type DynamoDBConnectionStore struct {
+	client DynamoDBConnectionAPI
+	table  string
+	ttl    time.Duration
+}
+
+// NewDynamoDBConnectionStore creates a new DynamoDBConnectionStore with the table.
+// The optFns can be used for DynamoDB local, such as setting BaseEndpoint.
+func NewDynamoDBConnectionStore(conf aws.Config, table string, optFns ...func(*dynamodb.Options)) *DynamoDBConnectionStore {
+	return NewDynamoDBConnectionStoreWithClient(dynamodb.NewFromConfig(conf, optFns...), table)
+}
+
+// NewDynamoDBConnectionStoreWithClient creates a new DynamoDBConnectionStore with the client.
+func NewDynamoDBConnectionStoreWithClient(client DynamoDBConnectionAPI, table string) *DynamoDBConnectionStore {
+	return &DynamoDBConnectionStore{
+		client: client,
+		table:  table,
+	}
+}
+
+// WithTTL Set 'ttl' attribute of the items to expire them after d, in case $disconnect is not delivered.
+// Time to Live of the table must be enabled with the attribute.
+func (s *DynamoDBConnectionStore) WithTTL(d time.Duration) *DynamoDBConnectionStore {
+	s.ttl = d
+	return s
+}
+
+func (s *DynamoDBConnectionStore) item(pk, sk string, conn *Connection) map[string]types.AttributeValue {
+	groups := make([]types.AttributeValue, 0, len(conn.Groups))
+	for _, g := range conn.Groups {
+		groups = append(groups, &types.AttributeValueMemberS{Value: g})
+	}
+	tags := make(map[string]types.AttributeValue, len(conn.Tags))
+	for k, v := range conn.Tags {
+		tags[k] = &types.AttributeValueMemberS{Value: v}
+	}
+
+	item := map[string]types.AttributeValue{
+		DynamoDBPartitionKey: &types.AttributeValueMemberS{Value: pk},
+		DynamoDBSortKey:      &types.AttributeValueMemberS{Value: sk},
+		"connectionId":       &types.AttributeValueMemberS{Value: conn.ID},
+		"groups":             &types.AttributeValueMemberL{Value: groups},
+		"tags":               &types.AttributeValueMemberM{Value: tags},
+		"connectedAt":        &types.AttributeValueMemberN{Value: strconv.FormatInt(conn.ConnectedAt.UnixMilli(), 10)},
+	}
+	if 0 < s.ttl {
+		item["ttl"] = &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Add(s.ttl).Unix(), 10)}
+	}
+	return item
+}
+
+func dynamoDBKey(pk, sk string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		DynamoDBPartitionKey: &types.AttributeValueMemberS{Value: pk},
+		DynamoDBSortKey:      &types.AttributeValueMemberS{Value: sk},
+	}
+}
+
+func connectionFromItem(item map[string]types.AttributeValue) *Connection {
+	conn := &Connection{}
+	if v, ok := item["connectionId"].(*types.AttributeValueMemberS); ok {
+		conn.ID = v.Value
+	}
+	if v, ok := item["groups"].(*types.AttributeValueMemberL); ok {
+		for _, g := range v.Value {
+			if s, ok := g.(*types.AttributeValueMemberS); ok {
+				conn.Groups = append(conn.Groups, s.Value)
+			}
+		}
+	}
+	if v, ok := item["tags"].(*types.AttributeValueMemberM); ok && 0 < len(v.Value) {
+		conn.Tags = make(map[string]string, len(v.Value))
+		for k, t := range v.Value {
+			if s, ok := t.(*types.AttributeValueMemberS); ok {
+				conn.Tags[k] = s.Value
+			}
+		}
+	}
+	if v, ok := item["connectedAt"].(*types.AttributeValueMemberN); ok {
+		if ms, err := strconv.ParseInt(v.Value, 10, 64); err == nil {
+			conn.ConnectedAt = time.UnixMilli(ms)
+		}
+	}
+	return conn
+}
+
+func (s *DynamoDBConnectionStore) PutConnection(ctx context.Context, conn *Connection) error {
+	items := []map[string]types.AttributeValue{
+		s.item(dynamoDBConnectionPrefix+conn.ID, dynamoDBConnectionSK, conn),
+		s.item(dynamoDBGroupPrefix+AllConnections, conn.ID, conn),
+	}
+	for _, g := range conn.Groups {
+		items = append(items, s.item(dynamoDBGroupPrefix+g, conn.ID, conn))
+	}
+	for _, item := range items {
+		if _, err := s.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item:      item,
+		}); err != nil {
+			return fmt.Errorf("dynamodb: put connection: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *DynamoDBConnectionStore) DeleteConnection(ctx context.Context, connectionID string) error {
+	out, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(s.table),
+		Key:            dynamoDBKey(dynamoDBConnectionPrefix+connectionID, dynamoDBConnectionSK),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return fmt.Errorf("dynamodb: get connection: %w", err)
+	}
+
+	// The item of the connection is deleted at last, so that the deletion can be retried.
+	keys := []map[string]types.AttributeValue{dynamoDBKey(dynamoDBGroupPrefix+AllConnections, connectionID)}
+	if out.Item != nil {
+		for _, g := range connectionFromItem(out.Item).Groups {
+			keys = append(keys, dynamoDBKey(dynamoDBGroupPrefix+g, connectionID))
+		}
+	}
+	keys = append(keys, dynamoDBKey(dynamoDBConnectionPrefix+connectionID, dynamoDBConnectionSK))
+
+	for _, key := range keys {
+		if _, err := s.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+			TableName: aws.String(s.table),
+			Key:       key,
+		}); err != nil {
+			return fmt.Errorf("dynamodb: delete connection: %w", err)
+		}
+	}
+	return nil
+}
+
+func (s *DynamoDBConnectionStore) ListConnections(ctx context.Context, group string) ([]*Connection, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("#pk = :pk"),
+		ExpressionAttributeNames: map[string]string{
+			"#pk": DynamoDBPartitionKey,
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: dynamoDBGroupPrefix + group},
+		},
+	}
+
+	var conns []*Connection
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("dynamodb: list connections: %w", err)
+		}
+		for _, item := range out.Items {
+			conns = append(conns, connectionFromItem(item))
+		}
+	}
+	return conns, nil
+}
@@ -0,0 +1,65 @@
+package aws
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"testing"
+	"time"
+)
+
+func TestDynamoDBConnectionStore(t *testing.T) {
+	server := awstest.NewDynamoDBServer()
+	defer server.Close()
+	server.CreateTable("connections", DynamoDBPartitionKey, DynamoDBSortKey)
+	// Query returns a single item for each page.
+	server.QueryLimit = 1
+
+	store := NewDynamoDBConnectionStore(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}, "connections", func(o *dynamodb.Options) {
+		o.BaseEndpoint = aws.String(server.URL())
+	}).WithTTL(time.Hour)
+
+	ctx := context.Background()
+	connectedAt := time.UnixMilli(time.Now().UnixMilli())
+	assert.NoError(t, store.PutConnection(ctx, &Connection{
+		ID:          "a",
+		Groups:      []string{"lobby", "admins"},
+		Tags:        map[string]string{"user": "alice"},
+		ConnectedAt: connectedAt,
+	}))
+	assert.NoError(t, store.PutConnection(ctx, &Connection{ID: "b", Groups: []string{"lobby"}, ConnectedAt: connectedAt}))
+
+	conns, err := store.ListConnections(ctx, "lobby")
+	assert.NoError(t, err)
+	if assert.Len(t, conns, 2) {
+		assert.Equal(t, &Connection{
+			ID:          "a",
+			Groups:      []string{"lobby", "admins"},
+			Tags:        map[string]string{"user": "alice"},
+			ConnectedAt: connectedAt,
+		}, conns[0])
+		assert.Equal(t, "b", conns[1].ID)
+	}
+
+	conns, err = store.ListConnections(ctx, AllConnections)
+	assert.NoError(t, err)
+	assert.Len(t, conns, 2)
+
+	assert.NoError(t, store.DeleteConnection(ctx, "a"))
+	assert.NoError(t, store.DeleteConnection(ctx, "unknown"))
+	conns, err = store.ListConnections(ctx, "admins")
+	assert.NoError(t, err)
+	assert.Empty(t, conns)
+
+	assert.NoError(t, store.DeleteConnection(ctx, "b"))
+	assert.Zero(t, server.ItemCount("connections"))
+
+	_, err = NewDynamoDBConnectionStoreWithClient(store.client, "unknown").ListConnections(ctx, AllConnections)
+	assert.ErrorContains(t, err, "ResourceNotFoundException")
+}
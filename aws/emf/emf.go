@@ -0,0 +1,240 @@
+// Package emf Emit metrics in CloudWatch Embedded Metric Format (EMF).
+// The documents written to stdout by Lambda functions are extracted into CloudWatch metrics.
+//
+// https://docs.aws.amazon.com/AmazonCloudWatch/latest/monitoring/CloudWatch_Embedded_Metric_Format_Specification.html
+package emf
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type Unit string
+
+const (
+	UnitNone         Unit = "None"
+	UnitCount        Unit = "Count"
+	UnitPercent      Unit = "Percent"
+	UnitSeconds      Unit = "Seconds"
+	UnitMilliseconds Unit = "Milliseconds"
+	UnitMicroseconds Unit = "Microseconds"
+	UnitBytes        Unit = "Bytes"
+	UnitKilobytes    Unit = "Kilobytes"
+	UnitMegabytes    Unit = "Megabytes"
+)
+
+const (
+	// MaxMetrics Maximum number of metrics in a document.
+	MaxMetrics = 100
+	// MaxDimensions Maximum number of dimensions in a dimension set.
+	MaxDimensions = 30
+)
+
+type Option func(l *Logger)
+
+// WithWriter Set the destination of the documents. The default is os.Stdout.
+func WithWriter(w io.Writer) Option {
+	return func(l *Logger) {
+		l.w = w
+	}
+}
+
+// WithDimensions Add a dimension set. The metrics are aggregated for each dimension set.
+// Dimensions without value in the document are omitted from the set.
+func WithDimensions(names ...string) Option {
+	return func(l *Logger) {
+		l.dimensions = append(l.dimensions, names)
+	}
+}
+
+// WithDefaultDimension Add the dimension with the fixed value to all dimension sets, such as the service name.
+func WithDefaultDimension(name, value string) Option {
+	return func(l *Logger) {
+		l.defaultDimensions = append(l.defaultDimensions, name)
+		l.defaultValues[name] = value
+	}
+}
+
+// Logger Writer of EMF documents for a namespace.
+type Logger struct {
+	namespace         string
+	dimensions        [][]string
+	defaultDimensions []string
+	defaultValues     map[string]string
+	w                 io.Writer
+	mu                sync.Mutex
+	now               func() time.Time
+}
+
+func New(namespace string, opts ...Option) *Logger {
+	l := &Logger{
+		namespace:     namespace,
+		defaultValues: map[string]string{},
+		w:             os.Stdout,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// NewDocument Create a document to collect the metrics of an invocation.
+func (l *Logger) NewDocument() *Document {
+	return &Document{
+		properties: map[string]any{},
+		index:      map[string]int{},
+	}
+}
+
+type metricDefinition struct {
+	Name string `json:"Name"`
+	Unit Unit   `json:"Unit,omitempty"`
+}
+
+type metricDirective struct {
+	Namespace  string             `json:"Namespace"`
+	Dimensions [][]string         `json:"Dimensions"`
+	Metrics    []metricDefinition `json:"Metrics"`
+}
+
+type metadata struct {
+	Timestamp         int64             `json:"Timestamp"`
+	CloudWatchMetrics []metricDirective `json:"CloudWatchMetrics"`
+}
+
+// Flush Write the document as a line of JSON. Nothing is written if the document has no metrics.
+// Metrics more than MaxMetrics are dropped.
+func (l *Logger) Flush(d *Document) error {
+	if d == nil {
+		return nil
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.metrics) == 0 {
+		return nil
+	}
+
+	root := make(map[string]any, len(d.properties)+len(d.metrics)+len(l.defaultValues)+1)
+	for k, v := range l.defaultValues {
+		root[k] = v
+	}
+	for k, v := range d.properties {
+		root[k] = v
+	}
+
+	directive := metricDirective{
+		Namespace:  l.namespace,
+		Dimensions: l.dimensionSets(root),
+	}
+	for i, m := range d.metrics {
+		if MaxMetrics <= i {
+			break
+		}
+		directive.Metrics = append(directive.Metrics, metricDefinition{Name: m.name, Unit: m.unit})
+		if len(m.values) == 1 {
+			root[m.name] = m.values[0]
+		} else {
+			root[m.name] = m.values
+		}
+	}
+
+	root["_aws"] = &metadata{
+		Timestamp:         l.now().UnixMilli(),
+		CloudWatchMetrics: []metricDirective{directive},
+	}
+
+	b, err := json.Marshal(root)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	_, err = l.w.Write(b)
+	return err
+}
+
+// dimensionSets Dimension sets with the values in root.
+func (l *Logger) dimensionSets(root map[string]any) [][]string {
+	sets := l.dimensions
+	if len(sets) == 0 {
+		sets = [][]string{{}}
+	}
+
+	result := make([][]string, 0, len(sets))
+	for _, set := range sets {
+		dims := make([]string, 0, len(l.defaultDimensions)+len(set))
+		for _, name := range append(append([]string{}, l.defaultDimensions...), set...) {
+			if _, ok := root[name].(string); ok && len(dims) < MaxDimensions {
+				dims = append(dims, name)
+			}
+		}
+		result = append(result, dims)
+	}
+	return result
+}
+
+type metric struct {
+	name   string
+	unit   Unit
+	values []float64
+}
+
+// Document Metrics and properties of an invocation. All methods are safe for concurrent use, and for nil.
+type Document struct {
+	mu         sync.Mutex
+	properties map[string]any
+	metrics    []*metric
+	index      map[string]int
+}
+
+// PutMetric Add the value of the metric. Values of the same metric are sent as an array.
+func (d *Document) PutMetric(name string, value float64, unit Unit) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if i, ok := d.index[name]; ok {
+		d.metrics[i].values = append(d.metrics[i].values, value)
+		return
+	}
+	d.index[name] = len(d.metrics)
+	d.metrics = append(d.metrics, &metric{name: name, unit: unit, values: []float64{value}})
+}
+
+// SetDimension Set the value of the dimension.
+func (d *Document) SetDimension(name, value string) {
+	d.SetProperty(name, value)
+}
+
+// SetProperty Set the property, that is searchable in CloudWatch Logs Insights but is not a dimension.
+func (d *Document) SetProperty(key string, value any) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.properties[key] = value
+}
+
+type documentContextKey struct{}
+
+// NewContext Return the context holding the document.
+func NewContext(ctx context.Context, d *Document) context.Context {
+	return context.WithValue(ctx, documentContextKey{}, d)
+}
+
+// FromContext Return the document of the invocation, or nil if metrics are not enabled.
+// Methods of Document are no-op for nil, so the result can be used without checking.
+func FromContext(ctx context.Context) *Document {
+	d, _ := ctx.Value(documentContextKey{}).(*Document)
+	return d
+}
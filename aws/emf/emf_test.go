@@ -0,0 +1,80 @@
+package emf
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestLogger_Flush(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("App",
+		WithWriter(&buf),
+		WithDefaultDimension("Service", "orders"),
+		WithDimensions("Route"),
+		WithDimensions("Route", "Missing"),
+	)
+	l.now = func() time.Time { return time.UnixMilli(1700000000000) }
+
+	d := l.NewDocument()
+	d.SetDimension("Route", "GET /items/{id}")
+	d.SetProperty("RequestId", "request-id")
+	d.PutMetric("Latency", 12.5, UnitMilliseconds)
+	d.PutMetric("Items", 1, UnitCount)
+	d.PutMetric("Items", 2, UnitCount)
+	assert.NoError(t, l.Flush(d))
+
+	assert.JSONEq(t, `{
+		"_aws": {
+			"Timestamp": 1700000000000,
+			"CloudWatchMetrics": [{
+				"Namespace": "App",
+				"Dimensions": [["Service", "Route"], ["Service", "Route"]],
+				"Metrics": [{"Name": "Latency", "Unit": "Milliseconds"}, {"Name": "Items", "Unit": "Count"}]
+			}]
+		},
+		"Service": "orders",
+		"Route": "GET /items/{id}",
+		"RequestId": "request-id",
+		"Latency": 12.5,
+		"Items": [1, 2]
+	}`, buf.String())
+
+	// documents without metrics are not written
+	buf.Reset()
+	assert.NoError(t, l.Flush(l.NewDocument()))
+	assert.Empty(t, buf.String())
+}
+
+func TestFromContext(t *testing.T) {
+	d := FromContext(context.Background())
+	assert.Nil(t, d)
+	// no-op for nil
+	d.PutMetric("Latency", 1, UnitMilliseconds)
+	d.SetProperty("key", "value")
+
+	l := New("App")
+	d = l.NewDocument()
+	assert.Same(t, d, FromContext(NewContext(context.Background(), d)))
+}
+
+func TestLogger_FlushWithoutDimensions(t *testing.T) {
+	var buf bytes.Buffer
+	l := New("App", WithWriter(&buf))
+	d := l.NewDocument()
+	d.PutMetric("Count", 1, UnitCount)
+	assert.NoError(t, l.Flush(d))
+
+	var doc struct {
+		AWS struct {
+			CloudWatchMetrics []struct {
+				Dimensions [][]string
+			}
+		} `json:"_aws"`
+	}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+	assert.Equal(t, [][]string{{}}, doc.AWS.CloudWatchMetrics[0].Dimensions)
+}
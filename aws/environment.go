@@ -0,0 +1,126 @@
+package aws
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// Values of AWS_LAMBDA_INITIALIZATION_TYPE.
+const (
+	InitializationTypeOnDemand               = "on-demand"
+	InitializationTypeProvisionedConcurrency = "provisioned-concurrency"
+	InitializationTypeSnapStart              = "snap-start"
+)
+
+// Environment Configuration of the execution environment, taken from the environment variables of Lambda.
+type Environment struct {
+	FunctionName    string
+	FunctionVersion string
+	// MemorySize Memory size of the function in MB.
+	MemorySize    int
+	Region        string
+	LogGroupName  string
+	LogStreamName string
+	// InitializationType One of InitializationTypeOnDemand, InitializationTypeProvisionedConcurrency and InitializationTypeSnapStart.
+	InitializationType string
+}
+
+// LambdaEnvironment Configuration of the current execution environment.
+var LambdaEnvironment = loadEnvironment()
+
+func loadEnvironment() *Environment {
+	region := os.Getenv("AWS_REGION")
+	if region == "" {
+		region = os.Getenv("AWS_DEFAULT_REGION")
+	}
+	return &Environment{
+		FunctionName:       lambdacontext.FunctionName,
+		FunctionVersion:    lambdacontext.FunctionVersion,
+		MemorySize:         lambdacontext.MemoryLimitInMB,
+		Region:             region,
+		LogGroupName:       lambdacontext.LogGroupName,
+		LogStreamName:      lambdacontext.LogStreamName,
+		InitializationType: initializationType(os.Getenv("AWS_LAMBDA_INITIALIZATION_TYPE")),
+	}
+}
+
+func initializationType(s string) string {
+	if s == "" {
+		return InitializationTypeOnDemand
+	}
+	return s
+}
+
+// IsSnapStart Whether the execution environment is restored from a SnapStart snapshot.
+func (e *Environment) IsSnapStart() bool {
+	return e.InitializationType == InitializationTypeSnapStart
+}
+
+// IsProvisionedConcurrency Whether the execution environment is initialized for provisioned concurrency.
+func (e *Environment) IsProvisionedConcurrency() bool {
+	return e.InitializationType == InitializationTypeProvisionedConcurrency
+}
+
+// invocationCount Number of invocations of the execution environment.
+var invocationCount atomic.Uint64
+
+// Invocation Information of the current invocation.
+type Invocation struct {
+	// ColdStart Whether this is the first invocation of the execution environment.
+	ColdStart bool
+	// Count Number of invocations of the execution environment, including this invocation.
+	Count uint64
+	// RequestID AWS request ID of the invocation.
+	RequestID string
+	// FunctionARN ARN of the invoked function, including the qualifier if invoked with an alias or a version.
+	FunctionARN string
+	// Deadline Time when the invocation times out.
+	Deadline    time.Time
+	Environment *Environment
+}
+
+// RemainingTime Time left until the invocation times out, or 0 if the deadline is unknown.
+func (i *Invocation) RemainingTime() time.Duration {
+	if i.Deadline.IsZero() {
+		return 0
+	}
+	return time.Until(i.Deadline)
+}
+
+// newInvocation Count the invocation, and create Invocation from the context of the runtime.
+func newInvocation(ctx context.Context) *Invocation {
+	count := invocationCount.Add(1)
+	inv := &Invocation{
+		ColdStart:   count == 1,
+		Count:       count,
+		Environment: LambdaEnvironment,
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		inv.RequestID = lc.AwsRequestID
+		inv.FunctionARN = lc.InvokedFunctionArn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		inv.Deadline = deadline
+	}
+	return inv
+}
+
+type invocationContextKey struct{}
+
+// withInvocation Hold Invocation in the context, unless the context already has it.
+func withInvocation(ctx context.Context) (context.Context, *Invocation) {
+	if inv, ok := GetInvocation(ctx); ok {
+		return ctx, inv
+	}
+	inv := newInvocation(ctx)
+	return context.WithValue(ctx, invocationContextKey{}, inv), inv
+}
+
+// GetInvocation Get the information of the current invocation, such as the cold start flag and the remaining time.
+func GetInvocation(ctx context.Context) (*Invocation, bool) {
+	inv, ok := ctx.Value(invocationContextKey{}).(*Invocation)
+	return inv, ok
+}
@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"errors"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestEnvironment(t *testing.T) {
+	t.Setenv("AWS_REGION", "ap-northeast-1")
+	t.Setenv("AWS_LAMBDA_INITIALIZATION_TYPE", "")
+	env := loadEnvironment()
+	assert.Equal(t, "ap-northeast-1", env.Region)
+	assert.Equal(t, InitializationTypeOnDemand, env.InitializationType)
+	assert.False(t, env.IsSnapStart())
+
+	t.Setenv("AWS_LAMBDA_INITIALIZATION_TYPE", InitializationTypeSnapStart)
+	assert.True(t, loadEnvironment().IsSnapStart())
+	t.Setenv("AWS_LAMBDA_INITIALIZATION_TYPE", InitializationTypeProvisionedConcurrency)
+	assert.True(t, loadEnvironment().IsProvisionedConcurrency())
+}
+
+func TestWithInvocation(t *testing.T) {
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{
+		AwsRequestID:       "request-id",
+		InvokedFunctionArn: awstest.DefaultFunctionARN,
+	})
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	_, ok := GetInvocation(ctx)
+	assert.False(t, ok)
+
+	ctx, inv := withInvocation(ctx)
+	assert.Equal(t, "request-id", inv.RequestID)
+	assert.Equal(t, awstest.DefaultFunctionARN, inv.FunctionARN)
+	assert.Equal(t, LambdaEnvironment, inv.Environment)
+	assert.NotZero(t, inv.Count)
+	assert.Equal(t, inv.Count == 1, inv.ColdStart)
+	assert.InDelta(t, time.Minute, inv.RemainingTime(), float64(time.Second))
+
+	// The invocation is counted only once.
+	_, again := withInvocation(ctx)
+	assert.Same(t, inv, again)
+
+	_, next := withInvocation(context.Background())
+	assert.Equal(t, inv.Count+1, next.Count)
+	assert.False(t, next.ColdStart)
+	assert.Zero(t, next.RemainingTime())
+}
+
+func TestLambdaAdaptor_LifecycleHooks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/invocation", func(writer http.ResponseWriter, request *http.Request) {
+		inv, ok := GetInvocation(request.Context())
+		if assert.True(t, ok) {
+			assert.Equal(t, awstest.DefaultFunctionARN, inv.FunctionARN)
+			assert.Positive(t, inv.RemainingTime())
+		}
+	})
+
+	var inits, firsts atomic.Int32
+	rt := startTestAdaptor(t, mux,
+		WithOnInit(func(ctx context.Context) error {
+			inits.Add(1)
+			return nil
+		}),
+		WithBeforeFirstInvoke(func(ctx context.Context) error {
+			_, ok := GetInvocation(ctx)
+			assert.True(t, ok)
+			firsts.Add(1)
+			return nil
+		}),
+	)
+
+	for i := 0; i < 2; i++ {
+		res, err := rt.Invoke(ctx, newFunctionURLEvent(t, http.MethodGet, "/invocation"))
+		assert.NoError(t, err)
+		assert.Nil(t, res.Error)
+	}
+	assert.EqualValues(t, 1, inits.Load())
+	assert.EqualValues(t, 1, firsts.Load())
+}
+
+func TestLambdaAdaptor_LifecycleHookErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	t.Run("init", func(t *testing.T) {
+		errInit := errors.New("init failed")
+		_, _, served := serveTestAdaptor(t, http.NotFoundHandler(), WithOnInit(func(ctx context.Context) error {
+			return errInit
+		}))
+		assert.ErrorIs(t, <-served, errInit)
+	})
+
+	t.Run("after restore", func(t *testing.T) {
+		defer func(env *Environment) { LambdaEnvironment = env }(LambdaEnvironment)
+		LambdaEnvironment = &Environment{InitializationType: InitializationTypeSnapStart}
+
+		var restores, firsts atomic.Int32
+		rt := startTestAdaptor(t, http.NotFoundHandler(),
+			WithAfterRestore(func(ctx context.Context) error {
+				if restores.Add(1) < 3 {
+					return errors.New("restore failed")
+				}
+				return nil
+			}),
+			WithBeforeFirstInvoke(func(ctx context.Context) error {
+				firsts.Add(1)
+				return nil
+			}),
+		)
+
+		// The invocations fail until the hook succeeds.
+		for i := 0; i < 2; i++ {
+			res, err := rt.Invoke(ctx, newFunctionURLEvent(t, http.MethodGet, "/"))
+			assert.NoError(t, err)
+			if assert.NotNil(t, res.Error) {
+				assert.Contains(t, res.Error.Message, "restore failed")
+			}
+		}
+		assert.Zero(t, firsts.Load())
+
+		for i := 0; i < 2; i++ {
+			res, err := rt.Invoke(ctx, newFunctionURLEvent(t, http.MethodGet, "/"))
+			assert.NoError(t, err)
+			assert.Nil(t, res.Error)
+		}
+		// The succeeded hooks are not run again.
+		assert.EqualValues(t, 3, restores.Load())
+		assert.EqualValues(t, 1, firsts.Load())
+	})
+}
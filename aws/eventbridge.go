@@ -0,0 +1,93 @@
+/*
+Package aws provides an implementation using aws-sdk-go.
+
+Lambda event type compatibility layer for Amazon EventBridge.
+
+See lambda event detail:
+https://docs.aws.amazon.com/eventbridge/latest/userguide/eb-events-structure.html
+*/
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultEventBridgeEventPathTemplate Default path template of EventBridge events.
+// Available parameters are {source}, {detail-type}, {region} and {account}.
+const DefaultEventBridgeEventPathTemplate = "/events/eventbridge/{source}/{detail-type}"
+
+const (
+	HTTPHeaderEventBridgeID         = "X-Amz-Eventbridge-Id"
+	HTTPHeaderEventBridgeSource     = "X-Amz-Eventbridge-Source"
+	HTTPHeaderEventBridgeDetailType = "X-Amz-Eventbridge-Detail-Type"
+	HTTPHeaderEventBridgeAccount    = "X-Amz-Eventbridge-Account"
+	HTTPHeaderEventBridgeRegion     = "X-Amz-Eventbridge-Region"
+	HTTPHeaderEventBridgeTime       = "X-Amz-Eventbridge-Time"
+	HTTPHeaderEventBridgeResources  = "X-Amz-Eventbridge-Resources"
+)
+
+// NewEventBridgeRequest EventBridge event to http.Request converter.
+// The 'detail' of the event is used as the request body, and the request path is built from pathTemplate.
+func NewEventBridgeRequest(ctx context.Context, e *events.EventBridgeEvent, pathTemplate string) (r *http.Request, err error) {
+	var (
+		body   = bytes.NewBuffer(e.Detail)
+		header = make(http.Header)
+	)
+
+	header.Set(HTTPHeaderEventBridgeID, e.ID)
+	header.Set(HTTPHeaderEventBridgeSource, e.Source)
+	header.Set(HTTPHeaderEventBridgeDetailType, e.DetailType)
+	header.Set(HTTPHeaderEventBridgeAccount, e.AccountID)
+	header.Set(HTTPHeaderEventBridgeRegion, e.Region)
+	if !e.Time.IsZero() {
+		header.Set(HTTPHeaderEventBridgeTime, e.Time.Format(time.RFC3339))
+	}
+	if 0 < len(e.Resources) {
+		header.Set(HTTPHeaderEventBridgeResources, strings.Join(e.Resources, ","))
+	}
+
+	header.Set(types.HTTPHeaderContentType, "application/json")
+	header.Set(types.HTTPHeaderContentLength, strconv.Itoa(body.Len()))
+
+	eventPath := ExpandPathParameters(pathTemplate, map[string]string{
+		"source":      url.PathEscape(e.Source),
+		"detail-type": url.PathEscape(e.DetailType),
+		"region":      url.PathEscape(e.Region),
+		"account":     url.PathEscape(e.AccountID),
+	})
+
+	// build raw request URL
+	rawURL := "http://localhost/" + strings.TrimLeft(eventPath, "/")
+
+	r, err = http.NewRequestWithContext(ctx, http.MethodPost, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("eventbridge: new request: %w", err)
+	}
+
+	r.Header = header
+	r.RemoteAddr = "127.0.0.1"
+	r.RequestURI = r.URL.RequestURI()
+	r.Host = r.URL.Host
+
+	if r.Header.Get(types.HTTPHeaderXRayTraceIDKey) == "" {
+		if traceID := ctx.Value(types.AWSXRayTraceIDContextKey); traceID != nil {
+			r.Header.Set(types.HTTPHeaderXRayTraceIDKey, fmt.Sprintf("%v", traceID))
+		}
+	}
+
+	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newEventBridgeRequestContext(e)))
+	r = withRequestLogger(r, "")
+
+	return
+}
@@ -0,0 +1,81 @@
+package aws
+
+import (
+	"context"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"net/http"
+	"time"
+)
+
+// DefaultHandlerTimeoutStatus Status of the response returned when the handler does not finish within the deadline.
+const DefaultHandlerTimeoutStatus = http.StatusGatewayTimeout
+
+// handlerTimeout Reserve the margin before the deadline of the invocation to return the response.
+// It is disabled when the margin is 0.
+type handlerTimeout struct {
+	margin time.Duration
+	status int
+	format ErrorResponseFormat
+}
+
+// withDeadline Derive the request with the deadline of the invocation shortened by the margin.
+// The returned cancel must be called after the handler finished.
+func (t *handlerTimeout) withDeadline(req *http.Request) (*http.Request, context.CancelFunc) {
+	if t.margin <= 0 {
+		return req, func() {}
+	}
+	deadline, ok := req.Context().Deadline()
+	if !ok {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithDeadline(req.Context(), deadline.Add(-t.margin))
+	return req.WithContext(ctx), cancel
+}
+
+// serve Serve the request with w, and return the writer holding the response.
+// If the handler does not finish within the deadline, the timeout response is returned instead of w,
+// and writes of the handler to w fail after that.
+func (t *handlerTimeout) serve(h http.Handler, w *ResponseWriter, req *http.Request) *ResponseWriter {
+	req, cancel := t.withDeadline(req)
+	defer cancel()
+	w.deadline.init(req.Context())
+
+	if t.margin <= 0 {
+		h.ServeHTTP(w, req)
+		return w
+	}
+
+	done := make(chan struct{})
+	var panicked any
+	go func() {
+		defer close(done)
+		defer func() {
+			panicked = recover()
+		}()
+		h.ServeHTTP(w, req)
+	}()
+
+	select {
+	case <-done:
+		if panicked != nil {
+			// re-panic on the invocation goroutine, as if the handler was called directly
+			panic(panicked)
+		}
+		return w
+	case <-req.Context().Done():
+		log.FromContext(req.Context()).Error("aws_lambda: handler did not finish within the deadline",
+			"method", req.Method, "path", req.URL.Path)
+		return t.timeoutResponse(w, invocationRequestID(req.Context()))
+	}
+}
+
+func (t *handlerTimeout) timeoutResponse(w *ResponseWriter, requestID string) *ResponseWriter {
+	status := t.status
+	if status == 0 {
+		status = DefaultHandlerTimeoutStatus
+	}
+	tw := NewResponseWriter()
+	tw.classifier = w.classifier
+	t.format.write(tw, status, requestID)
+	return tw
+}
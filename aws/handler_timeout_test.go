@@ -0,0 +1,64 @@
+package aws
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestLambdaHandler_HandlerTimeout(t *testing.T) {
+	written := make(chan error, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+		_, err := w.Write([]byte("too late"))
+		written <- err
+	})
+	mux.HandleFunc("/fast", func(w http.ResponseWriter, r *http.Request) {
+		deadline, ok := r.Context().Deadline()
+		assert.True(t, ok)
+		assert.True(t, time.Until(deadline) < 10*time.Second)
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	invoke := func(h *LambdaHandler, path string) *events.APIGatewayV2HTTPResponse {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second+200*time.Millisecond)
+		defer cancel()
+		ctx = lambdacontext.NewContext(ctx, &lambdacontext.LambdaContext{AwsRequestID: "request-id"})
+		res, err := h.InvokeHTTPAPI(ctx, &events.APIGatewayV2HTTPRequest{
+			RawPath: path,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{
+				HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet},
+			},
+		})
+		assert.NoError(t, err)
+		return res
+	}
+
+	h := NewLambdaHandlerWithOption(mux, []interface{}{WithHandlerTimeout(10*time.Second, 0)})
+
+	res := invoke(h, "/fast")
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "ok", res.Body)
+
+	res = invoke(h, "/slow")
+	assert.Equal(t, http.StatusGatewayTimeout, res.StatusCode)
+	assert.Contains(t, res.Body, "request id: request-id")
+	assert.ErrorIs(t, <-written, os.ErrDeadlineExceeded)
+
+	res = invoke(h, "/panic")
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+	h = NewLambdaHandlerWithOption(mux, []interface{}{WithHandlerTimeout(10*time.Second, http.StatusServiceUnavailable)})
+	res = invoke(h, "/slow")
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	<-written
+}
@@ -5,11 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/aws/aws-lambda-go/events"
-	"github.com/aws/aws-lambda-go/lambdaurl"
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/yacchi/lambda-http-adaptor/aws/emf"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 	"net/http"
+	"strings"
+	"time"
 )
 
 const DefaultNonHTTPEventPath = "/events"
@@ -32,6 +38,41 @@ func WithAWSConfigProvider(sp SDKConfigProvider) LambdaHandlerOption {
 	}
 }
 
+// WithAPIGatewayManagementAPI Set the client of the API Gateway management API for websocket routes,
+// instead of creating it from the AWS SDK providers.
+func WithAPIGatewayManagementAPI(client APIGatewayManagementAPI) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.apiGW = client
+	}
+}
+
+// WithBufferedWebsocketResponse Accumulate the writes of websocket handlers,
+// and post them as a single message on Flush or when the handler returns.
+// It is the same as WithWebsocketResponse(WebsocketBufferedResponse).
+func WithBufferedWebsocketResponse() LambdaHandlerOption {
+	return WithWebsocketResponse(WebsocketBufferedResponse)
+}
+
+// WithWebsocketResponse Set the default WebsocketResponseType of the websocket routes.
+// Without it, WebsocketResponseMode is used at each invocation.
+func WithWebsocketResponse(t WebsocketResponseType) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.wsResponseType = t
+	}
+}
+
+// WithWebsocketRouteResponse Set WebsocketResponseType of the route, such as returning the response of two-way routes
+// while posting the response of the other routes.
+// $connect and $disconnect routes always return the response, since messages can not be posted to the connection.
+func WithWebsocketRouteResponse(routeKey string, t WebsocketResponseType) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		if handler.wsRouteResponseTypes == nil {
+			handler.wsRouteResponseTypes = map[string]WebsocketResponseType{}
+		}
+		handler.wsRouteResponseTypes[routeKey] = t
+	}
+}
+
 func WithNonHTTPEventPath(path string) LambdaHandlerOption {
 	return func(handler *LambdaHandler) {
 		handler.nonHTTPEventPath = path
@@ -44,16 +85,237 @@ func WithoutNonHTTPEventPassThrough() LambdaHandlerOption {
 	}
 }
 
+// WithResponseStream Enable response streaming for Lambda Function URLs.
+// The Function URL must be configured with RESPONSE_STREAM invoke mode.
+// It is enabled by default when LAMBDA_INVOKE_MODE environment variable is 'response_stream'.
+func WithResponseStream() LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.responseStream = true
+	}
+}
+
+// WithResponseStreamSelector Enable response streaming for Lambda Function URLs only for requests matched by selector.
+// Other requests are buffered and returned as a single chunk.
+func WithResponseStreamSelector(selector func(r *http.Request) bool) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.responseStream = true
+		handler.responseStreamSelector = selector
+	}
+}
+
+// WithResponseStreamPaths Enable response streaming for Lambda Function URLs only for requests under the path prefixes.
+func WithResponseStreamPaths(prefixes ...string) LambdaHandlerOption {
+	return WithResponseStreamSelector(func(r *http.Request) bool {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(r.URL.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// WithSQSEventPath Set the destination path of SQS messages.
+// The default is DefaultSQSEventPath.
+func WithSQSEventPath(path string) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.sqsEventPath = path
+	}
+}
+
+// WithSQSQueueEventPath Set the destination path of SQS messages from the queue.
+// The queue can be specified with queue ARN or queue name.
+func WithSQSQueueEventPath(queue, path string) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		if handler.sqsQueueEventPaths == nil {
+			handler.sqsQueueEventPaths = map[string]string{}
+		}
+		handler.sqsQueueEventPaths[queue] = path
+	}
+}
+
+// WithSNSEventPathTemplate Set the destination path template of SNS notifications.
+// The default is DefaultSNSEventPathTemplate.
+func WithSNSEventPathTemplate(template string) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.snsEventPathTemplate = template
+	}
+}
+
+// WithEventBridgeEventPathTemplate Set the destination path template of EventBridge events.
+// The default is DefaultEventBridgeEventPathTemplate.
+func WithEventBridgeEventPathTemplate(template string) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.eventBridgeEventPathTemplate = template
+	}
+}
+
+// WithPayloadLimit Override the response payload limit, DefaultLambdaPayloadLimit or DefaultALBPayloadLimit.
+func WithPayloadLimit(limit int) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.payloadLimiter.limit = limit
+	}
+}
+
+// WithPayloadOverflowStrategy Set the strategy applied when the response payload exceeds the limit.
+// The default is RejectPayloadOverflow.
+func WithPayloadOverflowStrategy(strategy PayloadOverflowStrategy) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.payloadLimiter.strategy = strategy
+	}
+}
+
+// WithPayloadOverflowStatus Set the status of the response rejected by the payload limit, such as 413.
+// The default is 502.
+func WithPayloadOverflowStatus(status int) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.payloadLimiter.status = status
+	}
+}
+
+// WithPayloadOffload Upload the response body exceeding the payload limit to the store,
+// and redirect to the presigned URL valid for expires. Zero expires means DefaultPayloadOffloadExpires.
+func WithPayloadOffload(store ObjectStore, expires time.Duration) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.payloadLimiter.strategy = OffloadPayloadOverflow
+		handler.payloadLimiter.store = store
+		handler.payloadLimiter.offloadExpires = expires
+	}
+}
+
+// WithCompression Compress responses with the encoding negotiated from Accept-Encoding of the request.
+// gzip and deflate are available by default, and other encodings can be added by WithCompressionEncoder.
+func WithCompression() LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.compression.enabled = true
+	}
+}
+
+// WithCompressionEncoder Add or replace the encoder of the content encoding, and enable compression.
+// Encoders added later are preferred when the client accepts several encodings with the same q-value.
+func WithCompressionEncoder(encoding string, encoder EncoderFunc) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.compression.enabled = true
+		handler.compression.addEncoder(encoding, encoder)
+	}
+}
+
+// WithCompressionMinSize Set the minimum body size to compress. The default is DefaultCompressionMinSize.
+func WithCompressionMinSize(size int) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.compression.minSize = size
+	}
+}
+
+// WithCompressionMIMETypes Set the allowlist of MIME types to compress, such as 'application/json', 'text/*' and '*/*+json'.
+// By default, text content types are compressed. See utils.IsTextContent.
+func WithCompressionMIMETypes(mimeTypes ...string) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.compression.mimeTypes = mimeTypes
+	}
+}
+
+// WithContentClassifier Use the classifier to decide whether the response body is base64 encoded.
+// The default is utils.DefaultContentClassifier.
+func WithContentClassifier(classifier *utils.ContentClassifier) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.classifier = classifier
+	}
+}
+
+// WithHandlerTimeout Reserve the margin before the deadline of the invocation to return the response.
+// The context of http.Request has the deadline shortened by the margin, and if the handler does not finish by then,
+// the response with the status and the request ID is returned. Zero status means DefaultHandlerTimeoutStatus.
+// For streaming responses, only the deadline of the context is shortened, since the response may already be sent.
+func WithHandlerTimeout(margin time.Duration, status int) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.handlerTimeout.margin = margin
+		handler.handlerTimeout.status = status
+	}
+}
+
+// WithErrorResponseFormat Set the format of the error responses generated by the adaptor.
+// The default is PlainTextErrorResponse.
+func WithErrorResponseFormat(format ErrorResponseFormat) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.recovery.format = format
+		handler.handlerTimeout.format = format
+	}
+}
+
+// WithPanicStatus Set the status of the response returned when the handler panics.
+// The default is DefaultPanicStatus.
+func WithPanicStatus(status int) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.recovery.status = status
+	}
+}
+
+// WithTracerProvider Trace each invocation with a server span of the tracer provider.
+// The span is propagated to the handler with 'traceparent' header, and exported before the invocation returns
+// if the provider has ForceFlush method, such as the provider of OpenTelemetry SDK.
+func WithTracerProvider(tp trace.TracerProvider) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.tracing.provider = tp
+	}
+}
+
+// WithTracePropagator Set the propagator to propagate the span to the handler.
+// The default is propagation.TraceContext.
+func WithTracePropagator(p propagation.TextMapPropagator) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.tracing.propagator = p
+	}
+}
+
+// WithMetrics Emit the metrics of each invocation in CloudWatch Embedded Metric Format with the logger.
+// The handler can add custom metrics to the document of the invocation with emf.FromContext.
+func WithMetrics(logger *emf.Logger) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.metrics.logger = logger
+	}
+}
+
+// WithPanicHook Call the hook when the handler panics, such as reporting to an error tracker.
+func WithPanicHook(hook PanicHook) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.recovery.hook = hook
+	}
+}
+
+// WithConnectionRegistry Record the connections of API Gateway WebSocket API into the registry.
+// The connection is recorded when the $connect route responds with 2xx, and removed on the $disconnect route.
+func WithConnectionRegistry(registry *ConnectionRegistry) LambdaHandlerOption {
+	return func(handler *LambdaHandler) {
+		handler.connections = registry
+	}
+}
+
 type LambdaHandler struct {
-	httpHandler            http.Handler
-	sessProv               SDKSessionProvider
-	sess                   *session.Session
-	confProv               SDKConfigProvider
-	conf                   *aws.Config
-	apiGW                  APIGatewayManagementAPI
-	wsPathPrefix           string
-	nonHTTPEventPath       string
-	invokeLambdaWithStream func(ctx context.Context, request *events.LambdaFunctionURLRequest) (*events.LambdaFunctionURLStreamingResponse, error)
+	httpHandler                  http.Handler
+	sessProv                     SDKSessionProvider
+	sess                         *session.Session
+	confProv                     SDKConfigProvider
+	conf                         *aws.Config
+	apiGW                        APIGatewayManagementAPI
+	wsPathPrefix                 string
+	wsResponseType               WebsocketResponseType
+	wsRouteResponseTypes         map[string]WebsocketResponseType
+	nonHTTPEventPath             string
+	responseStream               bool
+	responseStreamSelector       func(r *http.Request) bool
+	sqsEventPath                 string
+	sqsQueueEventPaths           map[string]string
+	snsEventPathTemplate         string
+	eventBridgeEventPathTemplate string
+	payloadLimiter               payloadLimiter
+	compression                  compression
+	classifier                   *utils.ContentClassifier
+	handlerTimeout               handlerTimeout
+	recovery                     recovery
+	tracing                      tracing
+	metrics                      metrics
+	connections                  *ConnectionRegistry
 }
 
 type HandlerFunc func(ctx context.Context, payload json.RawMessage) (res any, err error)
@@ -64,9 +326,14 @@ func NewLambdaHandlerWithOption(h http.Handler, options []interface{}) *LambdaHa
 		confProv: func(ctx context.Context) (aws.Config, error) {
 			return config.LoadDefaultConfig(ctx)
 		},
-		wsPathPrefix:           DefaultWebsocketPathPrefix,
-		nonHTTPEventPath:       DefaultNonHTTPEventPath,
-		invokeLambdaWithStream: lambdaurl.Wrap(h),
+		wsPathPrefix:                 DefaultWebsocketPathPrefix,
+		nonHTTPEventPath:             DefaultNonHTTPEventPath,
+		responseStream:               LambdaInvokeMode == "response_stream",
+		sqsEventPath:                 DefaultSQSEventPath,
+		snsEventPathTemplate:         DefaultSNSEventPathTemplate,
+		eventBridgeEventPathTemplate: DefaultEventBridgeEventPathTemplate,
+		compression:                  newCompression(),
+		classifier:                   utils.DefaultContentClassifier,
 	}
 
 	for _, opt := range options {
@@ -75,6 +342,12 @@ func NewLambdaHandlerWithOption(h http.Handler, options []interface{}) *LambdaHa
 		}
 	}
 
+	if handler.compression.enabled {
+		// the encodings are registered to the copy, not to the classifier shared with other handlers
+		handler.classifier = handler.classifier.Clone()
+		handler.compression.registerEncodings(handler.classifier)
+	}
+
 	return handler
 }
 
@@ -82,39 +355,173 @@ func NewLambdaHandler(h http.Handler) *LambdaHandler {
 	return NewLambdaHandlerWithOption(h, nil)
 }
 
+// serveHTTP Call the handler, and recover from the panic into the error response.
+func (l *LambdaHandler) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	if l.tracing.enabled() {
+		l.tracing.annotate(l.httpHandler, req)
+	}
+	if l.metrics.enabled() {
+		l.metrics.annotate(l.httpHandler, req)
+	}
+	defer l.recovery.recover(w, req)
+	l.httpHandler.ServeHTTP(w, req)
+}
+
+// serveBuffered Serve the request with ResponseWriter, and post-process the buffered response.
+// The payload limit is not applied if payloadLimit is 0.
+func (l *LambdaHandler) serveBuffered(req *http.Request, payloadLimit int) *ResponseWriter {
+	w := NewResponseWriter()
+	w.classifier = l.classifier
+	w = l.handlerTimeout.serve(http.HandlerFunc(l.serveHTTP), w, req)
+	foldTrailers(w.headers)
+	l.compression.compress(req, w)
+	if payloadLimit != 0 {
+		l.payloadLimiter.limitPayload(req, w, payloadLimit)
+	}
+	return w
+}
+
 func (l *LambdaHandler) InvokeRESTAPI(ctx context.Context, e *events.APIGatewayProxyRequest) (r *events.APIGatewayProxyResponse, err error) {
 	req, multiValue, err := NewRESTAPIRequest(ctx, e)
 	if err != nil {
-		return nil, err
+		return RESTAPITargetResponse(l.recovery.badRequest(ctx, err), len(e.MultiValueHeaders) > 0)
 	}
 
-	w := NewResponseWriter()
-	l.httpHandler.ServeHTTP(w, req)
+	w := l.serveBuffered(req, DefaultLambdaPayloadLimit)
 	return RESTAPITargetResponse(w, multiValue)
 }
 
 func (l *LambdaHandler) InvokeHTTPAPI(ctx context.Context, e *events.APIGatewayV2HTTPRequest) (r *events.APIGatewayV2HTTPResponse, err error) {
 	req, err := NewHTTPAPIRequest(ctx, e)
 	if err != nil {
-		return nil, err
+		return HTTPAPIResponse(l.recovery.badRequest(ctx, err))
 	}
 
-	w := NewResponseWriter()
-	l.httpHandler.ServeHTTP(w, req)
+	w := l.serveBuffered(req, DefaultLambdaPayloadLimit)
 	return HTTPAPIResponse(w)
 }
 
 func (l *LambdaHandler) InvokeALBTargetGroup(ctx context.Context, request *events.ALBTargetGroupRequest) (r *events.ALBTargetGroupResponse, err error) {
 	req, multiValue, err := NewALBTargetGroupRequest(ctx, request)
 	if err != nil {
-		return nil, err
+		return ALBTargetResponse(l.recovery.badRequest(ctx, err), len(request.MultiValueHeaders) > 0)
 	}
 
-	w := NewResponseWriter()
-	l.httpHandler.ServeHTTP(w, req)
+	w := l.serveBuffered(req, DefaultALBPayloadLimit)
 	return ALBTargetResponse(w, multiValue)
 }
 
+func (l *LambdaHandler) InvokeFunctionURLStream(ctx context.Context, request *events.LambdaFunctionURLRequest) (r *events.LambdaFunctionURLStreamingResponse, err error) {
+	req, err := NewFunctionURLRequest(ctx, request)
+	if err != nil {
+		return FunctionURLBufferedStreamingResponse(l.recovery.badRequest(ctx, err))
+	}
+
+	if l.responseStreamSelector != nil && !l.responseStreamSelector(req) {
+		w := l.serveBuffered(req, 0)
+		return FunctionURLBufferedStreamingResponse(w)
+	}
+
+	req, cancel := l.handlerTimeout.withDeadline(req)
+	w := NewStreamingResponseWriter()
+	w.deadline.init(req.Context())
+	go func() {
+		defer w.Done()
+		defer cancel()
+		l.serveHTTP(w, req)
+	}()
+	return FunctionURLStreamingResponse(w)
+}
+
+func (l *LambdaHandler) sqsEventPathFor(arn string) string {
+	if path, ok := l.sqsQueueEventPaths[arn]; ok {
+		return path
+	}
+	if path, ok := l.sqsQueueEventPaths[SQSQueueName(arn)]; ok {
+		return path
+	}
+	return l.sqsEventPath
+}
+
+// InvokeSQS Dispatch each SQS message as an individual http.Request.
+// Messages that the handler responded with non-2xx status are reported as batch item failures.
+// To make use of it, ReportBatchItemFailures must be enabled on the event source mapping.
+// For FIFO queues, messages after the first failure are also reported as failures to keep ordering.
+func (l *LambdaHandler) InvokeSQS(ctx context.Context, e *events.SQSEvent) (r *events.SQSEventResponse, err error) {
+	r = &events.SQSEventResponse{
+		BatchItemFailures: []events.SQSBatchItemFailure{},
+	}
+
+	failedQueues := map[string]bool{}
+	for i := range e.Records {
+		m := &e.Records[i]
+
+		if failedQueues[m.EventSourceARN] && isFIFOQueue(m.EventSourceARN) {
+			r.BatchItemFailures = append(r.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: m.MessageId})
+			continue
+		}
+
+		req, err := NewSQSRequest(ctx, m, l.sqsEventPathFor(m.EventSourceARN))
+		if err != nil {
+			log.FromContext(ctx).Warn("sqs: can not convert message", "messageId", m.MessageId, "error", err)
+			r.BatchItemFailures = append(r.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: m.MessageId})
+			failedQueues[m.EventSourceARN] = true
+			continue
+		}
+
+		w := NewResponseWriter()
+		l.serveHTTP(w, req)
+		w.Done()
+
+		if !w.succeeded() {
+			r.BatchItemFailures = append(r.BatchItemFailures, events.SQSBatchItemFailure{ItemIdentifier: m.MessageId})
+			failedQueues[m.EventSourceARN] = true
+		}
+	}
+
+	return r, nil
+}
+
+// InvokeSNS Dispatch each SNS notification as an individual http.Request.
+// If the handler responded with non-2xx status, an error is returned to let Lambda retry the invocation.
+func (l *LambdaHandler) InvokeSNS(ctx context.Context, e *events.SNSEvent) (err error) {
+	for i := range e.Records {
+		record := &e.Records[i]
+
+		req, err := NewSNSRequest(ctx, record, l.snsEventPathTemplate)
+		if err != nil {
+			return err
+		}
+
+		w := NewResponseWriter()
+		l.serveHTTP(w, req)
+		w.Done()
+
+		if !w.succeeded() {
+			return fmt.Errorf("sns: handler responded with status %d for message %s", w.status, record.SNS.MessageID)
+		}
+	}
+	return nil
+}
+
+// InvokeEventBridge Dispatch EventBridge event as http.Request.
+// If the handler responded with non-2xx status, an error is returned to let Lambda retry the invocation.
+func (l *LambdaHandler) InvokeEventBridge(ctx context.Context, e *events.EventBridgeEvent) (err error) {
+	req, err := NewEventBridgeRequest(ctx, e, l.eventBridgeEventPathTemplate)
+	if err != nil {
+		return err
+	}
+
+	w := NewResponseWriter()
+	l.serveHTTP(w, req)
+	w.Done()
+
+	if !w.succeeded() {
+		return fmt.Errorf("eventbridge: handler responded with status %d for event %s", w.status, e.ID)
+	}
+	return nil
+}
+
 func (l *LambdaHandler) HandleNonHTTPEvent(ctx context.Context, event []byte, contentType string) ([]byte, error) {
 	if l.nonHTTPEventPath == "" {
 		return nil, fmt.Errorf("unknown lambda integration type and non-http event path is not set")
@@ -124,7 +531,11 @@ func (l *LambdaHandler) HandleNonHTTPEvent(ctx context.Context, event []byte, co
 		return nil, err
 	}
 	w := NewResponseWriter()
-	l.httpHandler.ServeHTTP(w, req)
+	l.serveHTTP(w, req)
+	// fail the invocation, so that Lambda retries the event or sends it to the failure destination
+	if !w.succeeded() {
+		return nil, fmt.Errorf("passthrough: handler responded with status %d", w.status)
+	}
 	return w.buf.Bytes(), nil
 }
 
@@ -158,24 +569,81 @@ func (l *LambdaHandler) ProvideAPIGatewayClient(ctx context.Context, request *ev
 	}
 }
 
+// recordConnection Record the accepted connection into the registry, or remove the disconnected one.
+// If the connection can not be recorded, it is rejected, since Broadcast could not reach it.
+func (l *LambdaHandler) recordConnection(w *ResponseWriter, req *http.Request, request *events.APIGatewayWebsocketProxyRequest) {
+	logger := log.FromContext(req.Context())
+	connectionID := request.RequestContext.ConnectionID
+	switch request.RequestContext.RouteKey {
+	case "$connect":
+		if http.StatusMultipleChoices <= w.status {
+			return
+		}
+		if err := l.connections.connect(req, connectionID); err != nil {
+			logger.Error("aws_lambda: record connection", "connectionId", connectionID, "error", err)
+			w.reset()
+			l.recovery.format.write(w, http.StatusInternalServerError, request.RequestContext.RequestID)
+		}
+	case "$disconnect":
+		if err := l.connections.disconnect(req.Context(), connectionID); err != nil {
+			logger.Warn("aws_lambda: remove connection", "connectionId", connectionID, "error", err)
+		}
+	}
+}
+
+// websocketResponseType WebsocketResponseType of the route.
+func (l *LambdaHandler) websocketResponseType(ctx context.Context, routeKey string) WebsocketResponseType {
+	switch routeKey {
+	case "$connect", "$disconnect":
+		return WebsocketReturnResponse
+	}
+	if t, ok := l.wsRouteResponseTypes[routeKey]; ok {
+		return t
+	}
+	if l.wsResponseType != "" {
+		return l.wsResponseType
+	}
+	t, ok := parseWebsocketResponseType(WebsocketResponseMode)
+	if !ok {
+		log.FromContext(ctx).Warn("aws_lambda: unknown websocket response mode, post_to_connection is used",
+			"mode", WebsocketResponseMode)
+	}
+	return t
+}
+
 func (l *LambdaHandler) InvokeWebsocketAPI(ctx context.Context, request *events.APIGatewayWebsocketProxyRequest) (r *events.APIGatewayProxyResponse, err error) {
 	req, multiValue, err := NewWebsocketRequest(ctx, request, l.wsPathPrefix)
 	if err != nil {
-		return nil, err
+		return RESTAPITargetResponse(l.recovery.badRequest(ctx, err), len(request.MultiValueHeaders) > 0)
 	}
 
-	routeKey := request.RequestContext.RouteKey
+	req = req.WithContext(newWebsocketConnectionContext(req.Context(), &WebsocketConnection{
+		ID: request.RequestContext.ConnectionID,
+		provide: func() (APIGatewayManagementAPI, error) {
+			return l.ProvideAPIGatewayClient(ctx, request)
+		},
+	}))
+
+	responseType := l.websocketResponseType(req.Context(), request.RequestContext.RouteKey)
 
-	if routeKey == "$connect" || routeKey == "$disconnect" || WebsocketResponseMode == "return" {
+	if responseType == WebsocketReturnResponse {
 		w := NewResponseWriter()
-		l.httpHandler.ServeHTTP(w, req)
+		w.classifier = l.classifier
+		w = l.handlerTimeout.serve(http.HandlerFunc(l.serveHTTP), w, req)
+		if l.connections != nil {
+			l.recordConnection(w, req, request)
+		}
 		return RESTAPITargetResponse(w, multiValue)
 	} else {
 		if apiGW, err := l.ProvideAPIGatewayClient(ctx, request); err != nil {
 			return nil, err
 		} else {
-			w := NewWebsocketResponseWriter(ctx, apiGW, request)
-			l.httpHandler.ServeHTTP(w, req)
+			req, cancel := l.handlerTimeout.withDeadline(req)
+			defer cancel()
+			w := NewWebsocketResponseWriter(req.Context(), apiGW, request)
+			w.buffered = responseType == WebsocketBufferedResponse
+			w.classifier = l.classifier
+			l.serveHTTP(w, req)
 			return WebsocketResponse(w, multiValue)
 		}
 	}
@@ -183,13 +651,38 @@ func (l *LambdaHandler) InvokeWebsocketAPI(ctx context.Context, request *events.
 
 func (l *LambdaHandler) Invoke(ctx context.Context, payload json.RawMessage) (res any, err error) {
 	var (
-		checker integrationTypeChecker
+		checker         integrationTypeChecker
+		integrationType = UnknownLambdaIntegrationType
 	)
 
-	if err = json.Unmarshal(payload, &checker); err != nil {
+	jsonErr := json.Unmarshal(payload, &checker)
+	if jsonErr == nil {
+		integrationType = checker.IntegrationType()
+	}
+	ctx, inv := withInvocation(ctx)
+	coldStart := inv.ColdStart
+
+	// The tracing span covers the metrics, since the deferred functions run in reverse order.
+	if l.tracing.enabled() {
+		var span trace.Span
+		ctx, span = l.tracing.start(ctx, integrationType, coldStart)
+		defer func() {
+			res, err = l.tracing.end(ctx, span, res, err)
+		}()
+	}
+	if l.metrics.enabled() {
+		var doc *emf.Document
+		started := time.Now()
+		ctx, doc = l.metrics.start(ctx, integrationType, coldStart)
+		defer func() {
+			res, err = l.metrics.end(ctx, doc, started, res, err)
+		}()
+	}
+
+	if jsonErr != nil {
 		res, err = l.HandleNonHTTPEvent(ctx, payload, http.DetectContentType(payload))
 	} else {
-		switch checker.IntegrationType() {
+		switch integrationType {
 		case APIGatewayRESTIntegration:
 			event := &events.APIGatewayProxyRequest{}
 			if err := json.Unmarshal(payload, event); err != nil {
@@ -214,13 +707,31 @@ func (l *LambdaHandler) Invoke(ctx context.Context, payload json.RawMessage) (re
 				return nil, err
 			}
 			res, err = l.InvokeWebsocketAPI(ctx, event)
+		case SQSIntegration:
+			event := &events.SQSEvent{}
+			if err := json.Unmarshal(payload, event); err != nil {
+				return nil, err
+			}
+			res, err = l.InvokeSQS(ctx, event)
+		case SNSIntegration:
+			event := &events.SNSEvent{}
+			if err := json.Unmarshal(payload, event); err != nil {
+				return nil, err
+			}
+			err = l.InvokeSNS(ctx, event)
+		case EventBridgeIntegration:
+			event := &events.EventBridgeEvent{}
+			if err := json.Unmarshal(payload, event); err != nil {
+				return nil, err
+			}
+			err = l.InvokeEventBridge(ctx, event)
 		case LambdaFunctionURLIntegration:
-			if LambdaInvokeMode == "response_stream" {
+			if l.responseStream {
 				event := &events.LambdaFunctionURLRequest{}
 				if err := json.Unmarshal(payload, event); err != nil {
 					return nil, err
 				}
-				res, err = l.invokeLambdaWithStream(ctx, event)
+				res, err = l.InvokeFunctionURLStream(ctx, event)
 			} else {
 				event := &events.APIGatewayV2HTTPRequest{}
 				if err := json.Unmarshal(payload, event); err != nil {
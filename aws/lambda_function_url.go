@@ -0,0 +1,125 @@
+/*
+Package aws provides an implementation using aws-sdk-go.
+
+Lambda event type compatibility layer for AWS Lambda Function URLs.
+
+See lambda event detail:
+https://docs.aws.amazon.com/lambda/latest/dg/urls-invocation.html#urls-payloads
+*/
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"net/http"
+	"strconv"
+)
+
+const HTTPHeaderSetCookie = types.HTTPHeaderSetCookie
+
+// NewFunctionURLRequest Lambda event type to http.Request converter for Lambda Function URLs.
+func NewFunctionURLRequest(ctx context.Context, e *events.LambdaFunctionURLRequest) (r *http.Request, err error) {
+	var (
+		body   *bytes.Buffer
+		header = make(http.Header)
+	)
+
+	reqCtx := e.RequestContext
+
+	for k, v := range e.Headers {
+		header.Set(k, v)
+	}
+
+	if header.Get(types.HTTPHeaderCookie) == "" {
+		header[types.HTTPHeaderCookie] = e.Cookies
+	}
+
+	// build raw request URL
+	rawURL := "http://" + reqCtx.DomainName + e.RawPath
+
+	if e.RawQueryString != "" {
+		rawURL += "?" + e.RawQueryString
+	}
+
+	// build body reader
+	if e.IsBase64Encoded {
+		b, err := base64.StdEncoding.DecodeString(e.Body)
+		if err != nil {
+			return nil, fmt.Errorf("function_url: decode base64 body: %w", err)
+		}
+		body = bytes.NewBuffer(b)
+	} else {
+		body = bytes.NewBufferString(e.Body)
+	}
+
+	r, err = http.NewRequestWithContext(ctx, reqCtx.HTTP.Method, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("function_url: new request: %w", err)
+	}
+
+	r.Header = header
+
+	if r.Header.Get(types.HTTPHeaderContentLength) == "" && body != nil {
+		r.Header.Set(types.HTTPHeaderContentLength, strconv.Itoa(body.Len()))
+	}
+
+	header.Set(types.HTTPHeaderXForwardedFor, reqCtx.HTTP.SourceIP)
+	// Function URLs only HTTPS
+	header.Set(types.HTTPHeaderXForwardedPort, "443")
+	header.Set(types.HTTPHeaderXForwardedProto, "https")
+
+	r.RemoteAddr = reqCtx.HTTP.SourceIP
+
+	r.RequestURI = r.URL.RequestURI()
+
+	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newFunctionURLRequestContext(e)))
+	r = withRequestLogger(r, "")
+
+	if r.Header.Get(types.HTTPHeaderXRayTraceIDKey) == "" {
+		if traceID := ctx.Value(types.AWSXRayTraceIDContextKey); traceID != nil {
+			r.Header.Set(types.HTTPHeaderXRayTraceIDKey, fmt.Sprintf("%v", traceID))
+		}
+	}
+
+	return
+}
+
+// FunctionURLStreamingResponse Response writer for Lambda Function URLs with RESPONSE_STREAM invoke mode.
+// It blocks until the handler writes the response header.
+func FunctionURLStreamingResponse(w *StreamingResponseWriter) (r *events.LambdaFunctionURLStreamingResponse, err error) {
+	<-w.ready
+
+	r = &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: w.status,
+		Body:       w.pr,
+	}
+	r.Headers, r.Cookies = functionURLHeaders(w.sentHeaders)
+	return
+}
+
+// FunctionURLBufferedStreamingResponse Response writer for Lambda Function URLs with RESPONSE_STREAM invoke mode
+// from the buffered response.
+func FunctionURLBufferedStreamingResponse(w *ResponseWriter) (r *events.LambdaFunctionURLStreamingResponse, err error) {
+	r = &events.LambdaFunctionURLStreamingResponse{
+		StatusCode: w.status,
+		Body:       bytes.NewReader(w.buf.Bytes()),
+	}
+	r.Headers, r.Cookies = functionURLHeaders(w.Header())
+
+	w.Done()
+	return
+}
+
+func functionURLHeaders(h http.Header) (headers map[string]string, cookies []string) {
+	cookies = h.Values(HTTPHeaderSetCookie)
+	h = h.Clone()
+	h.Del(HTTPHeaderSetCookie)
+	return utils.SemicolonSeparatedHeaderMap(h), cookies
+}
@@ -0,0 +1,101 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func newFunctionURLEvent(t *testing.T, method, path string) []byte {
+	event := events.LambdaFunctionURLRequest{
+		Version: "2.0",
+		RawPath: path,
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			RequestID:  "request-id",
+			DomainName: "abc.lambda-url.ap-northeast-1.on.aws",
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method: method,
+				Path:   path,
+			},
+		},
+	}
+	b, err := json.Marshal(event)
+	assert.NoError(t, err)
+	return b
+}
+
+func TestLambdaHandler_InvokeFunctionURLStream(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream", func(writer http.ResponseWriter, request *http.Request) {
+		_, ok := utils.RawRequestValue(request.Context())
+		assert.True(t, ok)
+		_, ok = GetFunctionURLRequestContext(request.Context())
+		assert.True(t, ok)
+
+		writer.Header().Set("Content-Type", "text/event-stream")
+		http.SetCookie(writer, &http.Cookie{Name: "session", Value: "1"})
+		writer.WriteHeader(http.StatusAccepted)
+		for _, m := range []string{"a", "b", "c"} {
+			_, err := writer.Write([]byte("data: " + m + "\n\n"))
+			assert.NoError(t, err)
+			writer.(http.Flusher).Flush()
+		}
+	})
+	mux.HandleFunc("/buffered", func(writer http.ResponseWriter, request *http.Request) {
+		_, isStreaming := writer.(*StreamingResponseWriter)
+		assert.False(t, isStreaming)
+		writer.Write([]byte("buffered"))
+	})
+
+	cases := []struct {
+		name    string
+		option  LambdaHandlerOption
+		path    string
+		status  int
+		body    string
+		cookies []string
+	}{
+		{
+			name:    "stream",
+			option:  WithResponseStream(),
+			path:    "/stream",
+			status:  http.StatusAccepted,
+			body:    "data: a\n\ndata: b\n\ndata: c\n\n",
+			cookies: []string{"session=1"},
+		},
+		{
+			name:   "buffered",
+			option: WithResponseStreamPaths("/stream"),
+			path:   "/buffered",
+			status: http.StatusOK,
+			body:   "buffered",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			h := NewLambdaHandlerWithOption(mux, []interface{}{c.option})
+
+			ret, err := h.Invoke(context.Background(), newFunctionURLEvent(t, http.MethodGet, c.path))
+			assert.NoError(t, err)
+
+			res, ok := ret.(*events.LambdaFunctionURLStreamingResponse)
+			if !ok {
+				t.Fatalf("unexpected response: %v", ret)
+			}
+			assert.Equal(t, c.status, res.StatusCode)
+			if c.cookies != nil {
+				assert.Equal(t, c.cookies, res.Cookies)
+			}
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, c.body, string(body))
+		})
+	}
+}
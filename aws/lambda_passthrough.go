@@ -40,6 +40,7 @@ func NewLambdaPassthroughRequest(ctx context.Context, payload []byte, eventPath
 	r.RemoteAddr = "127.0.0.1"
 	r.RequestURI = r.URL.RequestURI()
 	r.Host = r.URL.Host
+	r = withRequestLogger(r, "")
 
 	return
 }
@@ -0,0 +1,117 @@
+package aws
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type routeContextKey struct{}
+
+type routeTemplateContextKey struct{}
+
+// requestRoute Route of the request, such as 'GET /items/{id}'.
+func requestRoute(ctx context.Context) string {
+	route, _ := ctx.Value(routeContextKey{}).(string)
+	return route
+}
+
+// requestRouteTemplate Path template of the route given by the event, such as '/items/{id}',
+// or empty if the event has no template.
+func requestRouteTemplate(ctx context.Context) string {
+	template, _ := ctx.Value(routeTemplateContextKey{}).(string)
+	return template
+}
+
+// routeTemplate Path template of the request given by the event, or the pattern of http.ServeMux matched by the request.
+// It is empty if neither is known, so that the concrete path is not used as a low-cardinality route.
+func routeTemplate(h http.Handler, req *http.Request) string {
+	if template := requestRouteTemplate(req.Context()); template != "" {
+		return template
+	}
+	if mux, ok := h.(*http.ServeMux); ok {
+		if _, pattern := mux.Handler(req); pattern != "" {
+			return patternPath(pattern)
+		}
+	}
+	return ""
+}
+
+// patternPath Path of the route pattern, such as '/items/{id}' of 'GET /items/{id}' or 'GET example.com/items/{id}'.
+// It is empty if the pattern has no path, such as '$default' route of HTTP API and the route keys of WebSocket API.
+func patternPath(pattern string) string {
+	if _, p, ok := strings.Cut(pattern, " "); ok {
+		pattern = strings.TrimSpace(p)
+	}
+	if i := strings.Index(pattern, "/"); 0 <= i {
+		return pattern[i:]
+	}
+	return ""
+}
+
+// withRequestLogger Attach the route and the logger enriched with the request ID, the integration type, the route and the X-Ray trace ID.
+// If route is empty, the method and the path of the request are used.
+func withRequestLogger(r *http.Request, route string) *http.Request {
+	ctx := r.Context()
+	// The template is only taken from the route of the event, not from the concrete path of the fallback.
+	if template := patternPath(route); template != "" {
+		ctx = context.WithValue(ctx, routeTemplateContextKey{}, template)
+	}
+	if route == "" {
+		route = r.Method + " " + r.URL.Path
+	}
+	ctx = context.WithValue(ctx, routeContextKey{}, route)
+
+	attrs := make([]any, 0, 8)
+	if requestID := invocationRequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String("requestId", requestID))
+	}
+	if rc, ok := GetRequestContext(ctx); ok {
+		attrs = append(attrs, slog.String("integrationType", rc.IntegrationType()))
+	}
+	attrs = append(attrs, slog.String("route", route))
+	if traceID := xrayTraceID(ctx); traceID != "" {
+		attrs = append(attrs, slog.String("xrayTraceId", traceID))
+	}
+
+	return r.WithContext(log.NewContext(ctx, log.FromContext(ctx).With(attrs...)))
+}
+
+// xrayTraceHeader X-Ray trace header of the invocation, such as 'Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1'.
+func xrayTraceHeader(ctx context.Context) string {
+	if header, _ := ctx.Value(types.AWSXRayTraceIDContextKey).(string); header != "" {
+		return header
+	}
+	return os.Getenv("_X_AMZN_TRACE_ID")
+}
+
+// xrayTraceField Field of the X-Ray trace header, such as Root, Parent and Sampled.
+func xrayTraceField(header, key string) string {
+	for _, part := range strings.Split(header, ";") {
+		if k, v, ok := strings.Cut(strings.TrimSpace(part), "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// xrayTraceID Root trace ID of the X-Ray trace header of the invocation.
+func xrayTraceID(ctx context.Context) string {
+	return xrayTraceField(xrayTraceHeader(ctx), "Root")
+}
+
+// invocationRequestID Request ID of the Lambda invocation, or the request ID of the integration.
+func invocationRequestID(ctx context.Context) string {
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		return lc.AwsRequestID
+	}
+	if rc, ok := GetRequestContext(ctx); ok {
+		return rc.RequestID()
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+func TestLambdaHandler_RequestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	defaultLogger := log.Default()
+	log.SetDefault(slog.New(log.NewLambdaJSONHandler(&buf, nil)))
+	defer log.SetDefault(defaultLogger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items/", func(w http.ResponseWriter, r *http.Request) {
+		log.FromContext(r.Context()).Info("hello")
+	})
+	h := NewLambdaHandler(mux)
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "request-id"})
+	ctx = context.WithValue(ctx, types.AWSXRayTraceIDContextKey, "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1")
+	_, err := h.InvokeRESTAPI(ctx, &events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/items/1",
+		Resource:   "/items/{id}",
+	})
+	assert.NoError(t, err)
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "hello", record["message"])
+	assert.Equal(t, "request-id", record["requestId"])
+	assert.Equal(t, "rest_api", record["integrationType"])
+	assert.Equal(t, "GET /items/{id}", record["route"])
+	assert.Equal(t, "1-5759e988-bd862e3fe1be46a994272793", record["xrayTraceId"])
+}
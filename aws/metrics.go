@@ -0,0 +1,144 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/yacchi/lambda-http-adaptor/aws/emf"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Dimensions and properties of the invocation metrics.
+const (
+	DimensionIntegrationType = "IntegrationType"
+	DimensionMethod          = "Method"
+	DimensionRoute           = "Route"
+	DimensionStatusClass     = "StatusClass"
+	DimensionFunctionName    = "FunctionName"
+	PropertyStatusCode       = "StatusCode"
+	PropertyRequestID        = "RequestId"
+)
+
+// UnmatchedRoute Route dimension of the requests whose route template is unknown.
+const UnmatchedRoute = "unmatched"
+
+// Metrics of each invocation.
+const (
+	// MetricLatency Duration of the invocation until the response is returned, or streamed to the end.
+	MetricLatency = "Latency"
+	// MetricResponseSize Size of the response body in the Lambda response payload.
+	MetricResponseSize = "ResponseSize"
+	// MetricColdStart 1 for the first invocation of the execution environment, otherwise 0.
+	MetricColdStart = "ColdStart"
+	// MetricStatusClassPrefix Count of the responses of the status class, such as 'Status2xx' and 'Status5xx'.
+	MetricStatusClassPrefix = "Status"
+	// MetricErrors 1 if the invocation returned an error, otherwise 0.
+	MetricErrors = "Errors"
+)
+
+// metrics Invocation metrics emitted in EMF. It is disabled when logger is nil.
+type metrics struct {
+	logger *emf.Logger
+}
+
+func (m *metrics) enabled() bool {
+	return m.logger != nil
+}
+
+// start Create the document of the invocation, and hold it in the context.
+func (m *metrics) start(ctx context.Context, integrationType LambdaIntegrationType, coldStart bool) (context.Context, *emf.Document) {
+	d := m.logger.NewDocument()
+	d.SetDimension(DimensionIntegrationType, integrationType.String())
+	if lambdacontext.FunctionName != "" {
+		d.SetDimension(DimensionFunctionName, lambdacontext.FunctionName)
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		d.SetProperty(PropertyRequestID, lc.AwsRequestID)
+	}
+	if coldStart {
+		d.PutMetric(MetricColdStart, 1, emf.UnitCount)
+	} else {
+		d.PutMetric(MetricColdStart, 0, emf.UnitCount)
+	}
+	return emf.NewContext(ctx, d), d
+}
+
+// annotate Record the method and the route template of the HTTP request.
+// Requests without the template are recorded as UnmatchedRoute, to keep the cardinality of the dimension bounded.
+// Non-HTTP triggers, such as SQS and EventBridge, have neither the method nor the route.
+func (m *metrics) annotate(h http.Handler, req *http.Request) {
+	rc, ok := GetRequestContext(req.Context())
+	if !ok || !isHTTPIntegration(rc.IntegrationType()) {
+		return
+	}
+	route := routeTemplate(h, req)
+	if route == "" {
+		route = UnmatchedRoute
+	}
+	d := emf.FromContext(req.Context())
+	d.SetDimension(DimensionMethod, req.Method)
+	d.SetDimension(DimensionRoute, route)
+}
+
+// end Record the result of the invocation, and write the document.
+// For the streaming response, the document is written when the body is read to the end.
+func (m *metrics) end(ctx context.Context, d *emf.Document, started time.Time, res any, err error) (any, error) {
+	if err != nil {
+		d.PutMetric(MetricErrors, 1, emf.UnitCount)
+	} else {
+		d.PutMetric(MetricErrors, 0, emf.UnitCount)
+	}
+	if status := responseStatus(res); status != 0 {
+		d.SetProperty(PropertyStatusCode, status)
+		class := fmt.Sprintf("%dxx", status/100)
+		d.SetDimension(DimensionStatusClass, class)
+		d.PutMetric(MetricStatusClassPrefix+class, 1, emf.UnitCount)
+	}
+
+	finish := func(size int) {
+		d.PutMetric(MetricLatency, float64(time.Since(started).Microseconds())/1000, emf.UnitMilliseconds)
+		if 0 <= size {
+			d.PutMetric(MetricResponseSize, float64(size), emf.UnitBytes)
+		}
+		if err := m.logger.Flush(d); err != nil {
+			log.FromContext(ctx).Warn("aws_lambda: flush metrics", "error", err)
+		}
+	}
+
+	if stream, ok := res.(*events.LambdaFunctionURLStreamingResponse); ok && err == nil {
+		counter := &countingReader{r: stream.Body}
+		stream.Body = &inflightReader{r: counter, done: func() { finish(counter.n) }}
+		return res, nil
+	}
+
+	finish(responseSize(res))
+	return res, err
+}
+
+// responseSize Size of the response body, or -1 for non-HTTP responses.
+func responseSize(res any) int {
+	switch r := res.(type) {
+	case *events.APIGatewayProxyResponse:
+		return len(r.Body)
+	case *events.APIGatewayV2HTTPResponse:
+		return len(r.Body)
+	case *events.ALBTargetGroupResponse:
+		return len(r.Body)
+	}
+	return -1
+}
+
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
@@ -0,0 +1,95 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"github.com/yacchi/lambda-http-adaptor/aws/emf"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLambdaHandler_Metrics(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) {
+		emf.FromContext(r.Context()).PutMetric("ItemsViewed", 1, emf.UnitCount)
+		w.Write([]byte("item"))
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("streamed"))
+	})
+
+	var buf bytes.Buffer
+	logger := emf.New("App", emf.WithWriter(&buf), emf.WithDimensions(DimensionRoute))
+
+	decode := func() map[string]any {
+		var doc map[string]any
+		assert.NoError(t, json.Unmarshal(buf.Bytes(), &doc))
+		buf.Reset()
+		return doc
+	}
+
+	h := NewLambdaHandlerWithOption(mux, []interface{}{WithMetrics(logger)})
+	e, err := awstest.NewHTTPAPIRequest(httptest.NewRequest(http.MethodGet, "/items/1", nil), awstest.WithStage("$default"))
+	assert.NoError(t, err)
+	payload, _ := json.Marshal(e)
+	_, err = h.Invoke(context.Background(), payload)
+	assert.NoError(t, err)
+
+	doc := decode()
+	assert.Equal(t, "/items/{id}", doc[DimensionRoute])
+	assert.Equal(t, "GET", doc[DimensionMethod])
+	assert.Equal(t, "http_api", doc[DimensionIntegrationType])
+	assert.Equal(t, "2xx", doc[DimensionStatusClass])
+	assert.Equal(t, float64(1), doc["Status2xx"])
+	assert.Equal(t, float64(4), doc[MetricResponseSize])
+	assert.Equal(t, float64(1), doc["ItemsViewed"])
+	assert.Contains(t, doc, MetricLatency)
+	assert.Contains(t, doc, MetricColdStart)
+
+	h = NewLambdaHandlerWithOption(mux, []interface{}{WithMetrics(logger), WithResponseStream()})
+	fe, err := awstest.NewFunctionURLRequest(httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.NoError(t, err)
+	payload, _ = json.Marshal(fe)
+	res, err := h.Invoke(context.Background(), payload)
+	assert.NoError(t, err)
+
+	// The document is written when the body is read to the end.
+	assert.Empty(t, buf.String())
+	_, _ = io.ReadAll(res.(*events.LambdaFunctionURLStreamingResponse).Body)
+	doc = decode()
+	assert.Equal(t, "/stream", doc[DimensionRoute])
+	assert.Equal(t, float64(1), doc["Status4xx"])
+	assert.Equal(t, float64(8), doc[MetricResponseSize])
+	assert.Equal(t, float64(0), doc[MetricColdStart])
+
+	// Paths without the route template are not used as the dimension.
+	h = NewLambdaHandlerWithOption(mux, []interface{}{WithMetrics(logger)})
+	e, err = awstest.NewHTTPAPIRequest(httptest.NewRequest(http.MethodGet, "/users/12345", nil), awstest.WithStage("$default"))
+	assert.NoError(t, err)
+	payload, _ = json.Marshal(e)
+	_, err = h.Invoke(context.Background(), payload)
+	assert.NoError(t, err)
+	doc = decode()
+	assert.Equal(t, UnmatchedRoute, doc[DimensionRoute])
+	assert.Equal(t, "GET", doc[DimensionMethod])
+
+	// Batch triggers have neither the method nor the route.
+	payload, _ = json.Marshal(events.SQSEvent{
+		Records: []events.SQSMessage{
+			{MessageId: "1", Body: "{}", EventSource: "aws:sqs", EventSourceARN: "arn:aws:sqs:us-east-1:123456789012:orders"},
+		},
+	})
+	_, err = h.Invoke(context.Background(), payload)
+	assert.NoError(t, err)
+	doc = decode()
+	assert.Equal(t, "sqs", doc[DimensionIntegrationType])
+	assert.NotContains(t, doc, DimensionRoute)
+	assert.NotContains(t, doc, DimensionMethod)
+}
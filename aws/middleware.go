@@ -1,7 +1,6 @@
 package aws
 
 import (
-	"fmt"
 	"github.com/aws/aws-lambda-go/events"
 	"github.com/yacchi/lambda-http-adaptor/log"
 	"github.com/yacchi/lambda-http-adaptor/utils"
@@ -54,7 +53,8 @@ func WebsocketRouter(next http.Handler, routeExpression string) http.Handler {
 		// extract path from route key and expression
 		if routeKey == "$default" && routeExpression != "" {
 			if route, err := RouteSelector(event, routeExpression); err != nil {
-				log.Warning(fmt.Errorf("can not extract route: %w", err))
+				log.FromContext(request.Context()).Warn("aws_lambda: can not extract route",
+					"routeExpression", routeExpression, "error", err)
 			} else {
 				request.URL.Path = strings.Replace(request.URL.Path, routeKey, route, 1)
 			}
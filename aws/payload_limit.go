@@ -0,0 +1,201 @@
+package aws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultLambdaPayloadLimit Response payload limit of synchronous invocation.
+	DefaultLambdaPayloadLimit = 6 * 1024 * 1024
+	// DefaultALBPayloadLimit Response payload limit of Application Load Balancer Lambda target.
+	DefaultALBPayloadLimit = 1024 * 1024
+	// DefaultPayloadOffloadExpires Expiration of the presigned URL of offloaded response body.
+	DefaultPayloadOffloadExpires = 15 * time.Minute
+
+	// payloadEnvelopeSize Rough size of the JSON envelope other than headers and body, such as statusCode.
+	payloadEnvelopeSize = 256
+	// maxJSONEscapeRatio encoding/json escapes a byte into '\u00XX' in the worst case.
+	maxJSONEscapeRatio = 6
+)
+
+type PayloadOverflowStrategy int
+
+const (
+	// RejectPayloadOverflow Replace the response with an error response. The status is 502 Bad Gateway by default.
+	RejectPayloadOverflow PayloadOverflowStrategy = iota
+	// CompressPayloadOverflow Compress the body with gzip if the client accepts it, otherwise reject.
+	CompressPayloadOverflow
+	// OffloadPayloadOverflow Upload the body to ObjectStore, and redirect to the presigned URL.
+	OffloadPayloadOverflow
+)
+
+// ObjectStore Storage of response bodies exceeding the payload limit.
+type ObjectStore interface {
+	// PutObject Store the body. The contentEncoding, such as 'gzip', must be served with the object,
+	// since the body may be compressed by WithCompression or the handler.
+	PutObject(ctx context.Context, key, contentType, contentEncoding string, body []byte) error
+	PresignGetObject(ctx context.Context, key string, expires time.Duration) (string, error)
+}
+
+type payloadLimiter struct {
+	limit          int
+	strategy       PayloadOverflowStrategy
+	status         int
+	store          ObjectStore
+	offloadExpires time.Duration
+}
+
+// encodedResponseSize Compute the size of the response after encoding into the Lambda response payload.
+func encodedResponseSize(w *ResponseWriter) int {
+	return encodedHeaderSize(w) + encodedBodySize(w)
+}
+
+func encodedHeaderSize(w *ResponseWriter) int {
+	size := payloadEnvelopeSize
+	if b, err := json.Marshal(w.headers); err == nil {
+		size += len(b)
+	}
+	return size
+}
+
+func encodedBodySize(w *ResponseWriter) int {
+	if w.isBinary() {
+		return base64.StdEncoding.EncodedLen(w.buf.Len())
+	}
+	if b, err := json.Marshal(w.buf.String()); err == nil {
+		return len(b)
+	}
+	return maxJSONEscapeRatio * w.buf.Len()
+}
+
+// limitPayload Apply the overflow strategy when the response exceeds the limit.
+func (p *payloadLimiter) limitPayload(req *http.Request, w *ResponseWriter, limit int) {
+	if p.limit != 0 {
+		limit = p.limit
+	}
+
+	headerSize := encodedHeaderSize(w)
+	// fast path, the body never exceeds the limit even in the worst case of escaping
+	if headerSize+maxJSONEscapeRatio*w.buf.Len() <= limit {
+		return
+	}
+
+	size := headerSize + encodedBodySize(w)
+	if size <= limit {
+		return
+	}
+
+	reason := fmt.Sprintf("response payload of %s %s is %d bytes, exceeds the limit of %d bytes", req.Method, req.URL.Path, size, limit)
+
+	switch p.strategy {
+	case CompressPayloadOverflow:
+		if p.compress(req, w) {
+			if size = encodedResponseSize(w); size <= limit {
+				return
+			}
+			reason = fmt.Sprintf("%s even after compression (%d bytes)", reason, size)
+		} else {
+			reason += ", and the client does not accept gzip encoding"
+		}
+	case OffloadPayloadOverflow:
+		if err := p.offload(req, w); err != nil {
+			reason = fmt.Sprintf("%s, and offloading failed: %v", reason, err)
+		} else {
+			return
+		}
+	}
+
+	log.FromContext(req.Context()).Warn("aws_lambda: " + reason)
+	p.reject(w)
+}
+
+func (p *payloadLimiter) reject(w *ResponseWriter) {
+	status := p.status
+	if status == 0 {
+		status = http.StatusBadGateway
+	}
+	w.headers = http.Header{}
+	w.headers.Set(types.HTTPHeaderContentType, "text/plain; charset=utf-8")
+	w.status = status
+	w.buf.Reset()
+	w.buf.WriteString(http.StatusText(status))
+}
+
+func (p *payloadLimiter) compress(req *http.Request, w *ResponseWriter) bool {
+	if w.headers.Get(types.HTTPHeaderContentEncoding) != "" || !acceptsGzip(req) {
+		return false
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write(w.buf.Bytes()); err != nil {
+		return false
+	}
+	if err := gz.Close(); err != nil {
+		return false
+	}
+
+	w.buf = buf
+	w.headers.Set(types.HTTPHeaderContentEncoding, "gzip")
+	w.headers.Del(types.HTTPHeaderContentLength)
+	addVary(w.headers, "Accept-Encoding")
+	return true
+}
+
+func (p *payloadLimiter) offload(req *http.Request, w *ResponseWriter) error {
+	if p.store == nil {
+		return fmt.Errorf("object store is not set")
+	}
+
+	ctx := req.Context()
+	key := newObjectKey(ctx)
+	contentType := w.headers.Get(types.HTTPHeaderContentType)
+	contentEncoding := w.headers.Get(types.HTTPHeaderContentEncoding)
+	if err := p.store.PutObject(ctx, key, contentType, contentEncoding, w.buf.Bytes()); err != nil {
+		return err
+	}
+
+	expires := p.offloadExpires
+	if expires == 0 {
+		expires = DefaultPayloadOffloadExpires
+	}
+	location, err := p.store.PresignGetObject(ctx, key, expires)
+	if err != nil {
+		return err
+	}
+
+	w.headers = http.Header{}
+	w.headers.Set("Location", location)
+	w.headers.Set("Cache-Control", "no-store")
+	w.headers.Set(types.HTTPHeaderContentType, "text/plain; charset=utf-8")
+	w.status = http.StatusSeeOther
+	w.buf.Reset()
+	return nil
+}
+
+func acceptsGzip(req *http.Request) bool {
+	return acceptsEncoding(parseAcceptEncoding(req.Header.Values("Accept-Encoding")), "gzip")
+}
+
+// newObjectKey Object key of the offloaded body, prefixed with the Lambda request ID to be traceable.
+func newObjectKey(ctx context.Context) string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	suffix := hex.EncodeToString(b[:])
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		return lc.AwsRequestID + "-" + suffix
+	}
+	return suffix
+}
@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestLambdaHandler_PayloadLimit(t *testing.T) {
+	text := strings.Repeat("0123456789", 1024)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/text", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(text))
+	})
+	// random text is still larger than the limit after compression
+	b := make([]byte, 8*1024)
+	_, _ = rand.Read(b)
+	random := hex.EncodeToString(b)
+	mux.HandleFunc("/random", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(random))
+	})
+	mux.HandleFunc("/binary", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		// 5MB of binary exceeds 6MB after base64 encoding
+		w.Write(make([]byte, 5*1024*1024))
+	})
+
+	s3Server := awstest.NewS3Server()
+	defer s3Server.Close()
+	store := NewS3ObjectStore(aws.Config{
+		Region:      "us-east-1",
+		Credentials: credentials.NewStaticCredentialsProvider("key", "secret", ""),
+	}, "bucket", "responses", func(o *s3.Options) {
+		o.BaseEndpoint = aws.String(s3Server.URL())
+		o.UsePathStyle = true
+	})
+
+	invoke := func(t *testing.T, path, acceptEncoding string, options ...interface{}) *events.APIGatewayV2HTTPResponse {
+		e := &events.APIGatewayV2HTTPRequest{
+			Version:        "2.0",
+			RawPath:        path,
+			RequestContext: events.APIGatewayV2HTTPRequestContext{DomainName: "api.example.com"},
+		}
+		e.RequestContext.HTTP.Method = http.MethodGet
+		if acceptEncoding != "" {
+			e.Headers = map[string]string{"accept-encoding": acceptEncoding}
+		}
+		res, err := NewLambdaHandlerWithOption(mux, options).InvokeHTTPAPI(context.Background(), e)
+		assert.NoError(t, err)
+		return res
+	}
+
+	t.Run("within limit", func(t *testing.T) {
+		res := invoke(t, "/text", "")
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, text, res.Body)
+	})
+
+	t.Run("reject base64 inflation", func(t *testing.T) {
+		res := invoke(t, "/binary", "")
+		assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+		assert.False(t, res.IsBase64Encoded)
+	})
+
+	t.Run("reject with status", func(t *testing.T) {
+		res := invoke(t, "/text", "", WithPayloadLimit(1024), WithPayloadOverflowStatus(http.StatusRequestEntityTooLarge))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	t.Run("compress", func(t *testing.T) {
+		res := invoke(t, "/text", "br, gzip;q=0.8", WithPayloadLimit(1024), WithPayloadOverflowStrategy(CompressPayloadOverflow))
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "gzip", res.Headers["Content-Encoding"])
+		assert.True(t, res.IsBase64Encoded)
+
+		b, err := base64.StdEncoding.DecodeString(res.Body)
+		assert.NoError(t, err)
+		gz, err := gzip.NewReader(bytes.NewReader(b))
+		assert.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, text, string(body))
+	})
+
+	t.Run("compress not accepted", func(t *testing.T) {
+		for _, acceptEncoding := range []string{"gzip;q=0", "gzip;q=0.0", "gzip; q=0.000, br", "*;q=0"} {
+			res := invoke(t, "/text", acceptEncoding, WithPayloadLimit(1024), WithPayloadOverflowStrategy(CompressPayloadOverflow))
+			assert.Equal(t, http.StatusBadGateway, res.StatusCode, acceptEncoding)
+		}
+	})
+
+	t.Run("offload", func(t *testing.T) {
+		res := invoke(t, "/text", "", WithPayloadLimit(1024), WithPayloadOffload(store, 0))
+		assert.Equal(t, http.StatusSeeOther, res.StatusCode)
+
+		location := res.Headers["Location"]
+		assert.True(t, strings.HasPrefix(location, s3Server.URL()+"/bucket/responses/"), location)
+		assert.Contains(t, location, "X-Amz-Signature")
+
+		got, err := http.Get(location)
+		assert.NoError(t, err)
+		defer got.Body.Close()
+		body, _ := io.ReadAll(got.Body)
+		assert.Equal(t, text, string(body))
+		assert.Equal(t, "text/plain", got.Header.Get("Content-Type"))
+	})
+
+	t.Run("offload compressed", func(t *testing.T) {
+		res := invoke(t, "/random", "gzip", WithPayloadLimit(1024), WithCompression(), WithPayloadOffload(store, 0))
+		assert.Equal(t, http.StatusSeeOther, res.StatusCode)
+
+		// the object is served with the encoding of the compressed body
+		client := &http.Client{Transport: &http.Transport{DisableCompression: true}}
+		got, err := client.Get(res.Headers["Location"])
+		assert.NoError(t, err)
+		defer got.Body.Close()
+		assert.Equal(t, "gzip", got.Header.Get("Content-Encoding"))
+		gz, err := gzip.NewReader(got.Body)
+		assert.NoError(t, err)
+		body, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, random, string(body))
+	})
+}
+
+func TestLambdaHandler_ALBPayloadLimit(t *testing.T) {
+	h := NewLambdaHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(make([]byte, 1024*1024))
+	}))
+	res, err := h.InvokeALBTargetGroup(context.Background(), &events.ALBTargetGroupRequest{HTTPMethod: http.MethodGet, Path: "/"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+	assert.Equal(t, "502 Bad Gateway", res.StatusDescription)
+}
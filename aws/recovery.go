@@ -0,0 +1,131 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"runtime/debug"
+)
+
+// DefaultPanicStatus Status of the response returned when the handler panics.
+const DefaultPanicStatus = http.StatusInternalServerError
+
+// ErrorResponseFormat Format of the error responses generated by the adaptor,
+// such as panic recovery, request conversion failure and handler timeout.
+type ErrorResponseFormat int
+
+const (
+	// PlainTextErrorResponse The status text and the request ID in text/plain.
+	PlainTextErrorResponse ErrorResponseFormat = iota
+	// JSONErrorResponse {"message": "<status text>", "requestId": "<request id>"} in application/json.
+	JSONErrorResponse
+)
+
+type errorResponseBody struct {
+	Message   string `json:"message"`
+	RequestID string `json:"requestId,omitempty"`
+}
+
+// write Write the error response to w. The header must not be written yet.
+func (f ErrorResponseFormat) write(w http.ResponseWriter, status int, requestID string) {
+	switch f {
+	case JSONErrorResponse:
+		w.Header().Set(types.HTTPHeaderContentType, "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(&errorResponseBody{
+			Message:   http.StatusText(status),
+			RequestID: requestID,
+		})
+	default:
+		w.Header().Set(types.HTTPHeaderContentType, "text/plain; charset=utf-8")
+		w.WriteHeader(status)
+		_, _ = fmt.Fprintf(w, "%s\nrequest id: %s\n", http.StatusText(status), requestID)
+	}
+}
+
+// PanicHook Called with the request, the recovered value and the stack trace when the handler panics,
+// to report it to an error tracker.
+type PanicHook func(r *http.Request, recovered any, stack []byte)
+
+type recovery struct {
+	status int
+	format ErrorResponseFormat
+	hook   PanicHook
+}
+
+// recover Recover from the panic of the handler, and replace the response with the error response.
+// It must be called with defer.
+func (p *recovery) recover(w http.ResponseWriter, req *http.Request) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+
+	if recovered == http.ErrAbortHandler {
+		p.abort(w)
+		return
+	}
+
+	requestID := invocationRequestID(req.Context())
+	stack := debug.Stack()
+	log.FromContext(req.Context()).Error("aws_lambda: panic serving request",
+		"method", req.Method, "path", req.URL.Path, "panic", fmt.Sprint(recovered), "stack", string(stack))
+	if p.hook != nil {
+		p.hook(req, recovered, stack)
+	}
+
+	status := p.status
+	if status == 0 {
+		status = DefaultPanicStatus
+	}
+
+	switch w := w.(type) {
+	case *ResponseWriter:
+		w.reset()
+		p.format.write(w, status, requestID)
+	case *StreamingResponseWriter:
+		if w.wroteHeader {
+			// the response is partially sent, abort the stream to notify the client
+			w.abort(fmt.Errorf("aws_lambda: panic serving %s: %v", req.URL.Path, recovered))
+		} else {
+			p.format.write(w, status, requestID)
+		}
+	case *WebsocketResponseWriter:
+		// the body would be posted to the connection, so only the status is returned to API Gateway
+		if w.buffered {
+			w.discard(status)
+		} else if !w.wroteHeader {
+			w.WriteHeader(status)
+		}
+	}
+}
+
+// abort Abort the response silently for http.ErrAbortHandler, as net/http does, without the log and the hook.
+// The buffered response can not be aborted, so the partial response is replaced with the empty 502 response.
+func (p *recovery) abort(w http.ResponseWriter) {
+	switch w := w.(type) {
+	case *ResponseWriter:
+		w.reset()
+		w.WriteHeader(http.StatusBadGateway)
+	case *StreamingResponseWriter:
+		w.abort(http.ErrAbortHandler)
+	case *WebsocketResponseWriter:
+		if w.buffered {
+			w.discard(http.StatusBadGateway)
+		} else if !w.wroteHeader {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}
+}
+
+// badRequest Error response of the event that can not be converted into http.Request.
+func (p *recovery) badRequest(ctx context.Context, err error) *ResponseWriter {
+	requestID := invocationRequestID(ctx)
+	log.FromContext(ctx).Warn("aws_lambda: can not convert the event into http request", "requestId", requestID, "error", err)
+	w := NewResponseWriter()
+	p.format.write(w, http.StatusBadRequest, requestID)
+	return w
+}
@@ -0,0 +1,150 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestLambdaHandler_PanicRecovery(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/panic", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Partial", "1")
+		w.Write([]byte("partial"))
+		panic("boom")
+	})
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "request-id"})
+	event := &events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/panic"}
+
+	h := NewLambdaHandler(mux)
+	res, err := h.InvokeRESTAPI(ctx, event)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Equal(t, "Internal Server Error\nrequest id: request-id\n", res.Body)
+	assert.Empty(t, res.Headers["X-Partial"])
+
+	var (
+		hooked    any
+		hookedReq *http.Request
+	)
+	h = NewLambdaHandlerWithOption(mux, []interface{}{
+		WithErrorResponseFormat(JSONErrorResponse),
+		WithPanicStatus(http.StatusServiceUnavailable),
+		WithPanicHook(func(r *http.Request, recovered any, stack []byte) {
+			hooked = recovered
+			hookedReq = r
+			assert.NotEmpty(t, stack)
+		}),
+	})
+	res, err = h.InvokeRESTAPI(ctx, event)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	assert.Equal(t, "application/json", res.Headers["Content-Type"])
+	assert.JSONEq(t, `{"message":"Service Unavailable","requestId":"request-id"}`, res.Body)
+	assert.Equal(t, "boom", hooked)
+	assert.Equal(t, "/panic", hookedReq.URL.Path)
+}
+
+func TestLambdaHandler_PanicRecoveryStreaming(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/before", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+	mux.HandleFunc("/after", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		panic("boom")
+	})
+	h := NewLambdaHandlerWithOption(mux, []interface{}{WithResponseStream()})
+
+	res, err := h.Invoke(context.Background(), newFunctionURLEvent(t, http.MethodGet, "/before"))
+	assert.NoError(t, err)
+	stream := res.(*events.LambdaFunctionURLStreamingResponse)
+	assert.Equal(t, http.StatusInternalServerError, stream.StatusCode)
+
+	res, err = h.Invoke(context.Background(), newFunctionURLEvent(t, http.MethodGet, "/after"))
+	assert.NoError(t, err)
+	stream = res.(*events.LambdaFunctionURLStreamingResponse)
+	assert.Equal(t, http.StatusOK, stream.StatusCode)
+	body, err := io.ReadAll(stream.Body)
+	assert.Error(t, err)
+	assert.Equal(t, "partial", string(body))
+}
+
+func TestLambdaHandler_PanicRecoveryAbort(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/abort", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		panic(http.ErrAbortHandler)
+	})
+	hooked := false
+	hook := WithPanicHook(func(r *http.Request, recovered any, stack []byte) {
+		hooked = true
+	})
+
+	h := NewLambdaHandlerWithOption(mux, []interface{}{hook})
+	res, err := h.InvokeRESTAPI(context.Background(), &events.APIGatewayProxyRequest{HTTPMethod: http.MethodGet, Path: "/abort"})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadGateway, res.StatusCode)
+	assert.Empty(t, res.Body)
+
+	h = NewLambdaHandlerWithOption(mux, []interface{}{hook, WithResponseStream()})
+	ret, err := h.Invoke(context.Background(), newFunctionURLEvent(t, http.MethodGet, "/abort"))
+	assert.NoError(t, err)
+	body, err := io.ReadAll(ret.(*events.LambdaFunctionURLStreamingResponse).Body)
+	assert.ErrorIs(t, err, http.ErrAbortHandler)
+	assert.Equal(t, "partial", string(body))
+
+	// the abort is not reported as a panic
+	assert.False(t, hooked)
+}
+
+func TestLambdaHandler_BadRequest(t *testing.T) {
+	h := NewLambdaHandlerWithOption(http.NotFoundHandler(), []interface{}{WithErrorResponseFormat(JSONErrorResponse)})
+
+	res, err := h.InvokeHTTPAPI(context.Background(), &events.APIGatewayV2HTTPRequest{
+		RawPath:         "/",
+		Body:            "not base64!",
+		IsBase64Encoded: true,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RequestID: "api-request-id",
+			HTTP:      events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodPost},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	var body errorResponseBody
+	assert.NoError(t, json.Unmarshal([]byte(res.Body), &body))
+	assert.Equal(t, "Bad Request", body.Message)
+
+	alb, err := h.InvokeALBTargetGroup(context.Background(), &events.ALBTargetGroupRequest{
+		HTTPMethod:        http.MethodPost,
+		Path:              "/",
+		Body:              "not base64!",
+		IsBase64Encoded:   true,
+		MultiValueHeaders: map[string][]string{"Host": {"example.com"}},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, alb.StatusCode)
+	assert.NotEmpty(t, alb.MultiValueHeaders)
+}
+
+func TestLambdaHandler_PanicRecoveryPassthrough(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	})
+
+	h := NewLambdaHandler(mux)
+	res, err := h.Invoke(context.Background(), json.RawMessage(`{"Records":[{"eventSource":"aws:kinesis"}]}`))
+	assert.Error(t, err)
+	assert.Nil(t, res)
+}
@@ -0,0 +1,195 @@
+package aws
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// requestContext types.RequestContext implementation shared by all integrations.
+type requestContext struct {
+	requestID       string
+	stage           string
+	sourceIP        string
+	userAgent       string
+	domainName      string
+	claims          map[string]interface{}
+	integrationType LambdaIntegrationType
+	requestTime     time.Time
+}
+
+var _ types.RequestContext = (*requestContext)(nil)
+
+func (c *requestContext) RequestID() string {
+	return c.requestID
+}
+
+func (c *requestContext) Stage() string {
+	return c.stage
+}
+
+func (c *requestContext) SourceIP() string {
+	return c.sourceIP
+}
+
+func (c *requestContext) UserAgent() string {
+	return c.userAgent
+}
+
+func (c *requestContext) DomainName() string {
+	return c.domainName
+}
+
+func (c *requestContext) AuthorizerClaims() map[string]interface{} {
+	return c.claims
+}
+
+func (c *requestContext) IntegrationType() string {
+	return c.integrationType.String()
+}
+
+func (c *requestContext) RequestTime() time.Time {
+	return c.requestTime
+}
+
+func epochMilliTime(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func newRESTAPIRequestContext(e *events.APIGatewayProxyRequest) *requestContext {
+	reqCtx := &e.RequestContext
+	c := &requestContext{
+		requestID:       reqCtx.RequestID,
+		stage:           reqCtx.Stage,
+		sourceIP:        reqCtx.Identity.SourceIP,
+		userAgent:       reqCtx.Identity.UserAgent,
+		domainName:      reqCtx.DomainName,
+		integrationType: APIGatewayRESTIntegration,
+		requestTime:     epochMilliTime(reqCtx.RequestTimeEpoch),
+	}
+	if reqCtx.Authorizer != nil {
+		// Cognito user pool authorizer puts claims under the 'claims' key.
+		// Lambda authorizer puts its context values directly.
+		if claims, ok := reqCtx.Authorizer["claims"].(map[string]interface{}); ok {
+			c.claims = claims
+		} else {
+			c.claims = reqCtx.Authorizer
+		}
+	}
+	return c
+}
+
+func newHTTPAPIRequestContext(e *events.APIGatewayV2HTTPRequest) *requestContext {
+	reqCtx := &e.RequestContext
+	c := &requestContext{
+		requestID:       reqCtx.RequestID,
+		stage:           reqCtx.Stage,
+		sourceIP:        reqCtx.HTTP.SourceIP,
+		userAgent:       reqCtx.HTTP.UserAgent,
+		domainName:      reqCtx.DomainName,
+		integrationType: APIGatewayHTTPIntegration,
+		requestTime:     epochMilliTime(reqCtx.TimeEpoch),
+	}
+	// Function URLs without streaming mode are converted with the HTTP API converter.
+	if isFunctionURLDomain(reqCtx.DomainName) {
+		c.integrationType = LambdaFunctionURLIntegration
+		c.stage = ""
+	}
+	if auth := reqCtx.Authorizer; auth != nil {
+		if auth.JWT != nil {
+			c.claims = make(map[string]interface{}, len(auth.JWT.Claims))
+			for k, v := range auth.JWT.Claims {
+				c.claims[k] = v
+			}
+		} else if auth.Lambda != nil {
+			c.claims = auth.Lambda
+		}
+	}
+	return c
+}
+
+func newALBTargetGroupRequestContext(ctx context.Context, header http.Header) *requestContext {
+	c := &requestContext{
+		userAgent:       header.Get("User-Agent"),
+		domainName:      header.Get(types.HTTPHeaderHost),
+		integrationType: ALBTargetGroupIntegration,
+	}
+	// ALB appends the client address at the end of X-Forwarded-For.
+	if xff := header.Get(types.HTTPHeaderXForwardedFor); xff != "" {
+		addrs := strings.Split(xff, ",")
+		c.sourceIP = strings.TrimSpace(addrs[len(addrs)-1])
+	}
+	// ALB does not issue request id, so use the one of the lambda invocation.
+	if lc, ok := lambdacontext.FromContext(ctx); ok {
+		c.requestID = lc.AwsRequestID
+	}
+	return c
+}
+
+func newWebsocketRequestContext(e *events.APIGatewayWebsocketProxyRequest) *requestContext {
+	reqCtx := &e.RequestContext
+	c := &requestContext{
+		requestID:       reqCtx.RequestID,
+		stage:           reqCtx.Stage,
+		sourceIP:        reqCtx.Identity.SourceIP,
+		userAgent:       reqCtx.Identity.UserAgent,
+		domainName:      reqCtx.DomainName,
+		integrationType: APIGatewayWebsocketIntegration,
+		requestTime:     epochMilliTime(reqCtx.RequestTimeEpoch),
+	}
+	if claims, ok := reqCtx.Authorizer.(map[string]interface{}); ok {
+		c.claims = claims
+	}
+	return c
+}
+
+func isFunctionURLDomain(domain string) bool {
+	return strings.Contains(domain, ".lambda-url.")
+}
+
+func newFunctionURLRequestContext(e *events.LambdaFunctionURLRequest) *requestContext {
+	reqCtx := &e.RequestContext
+	return &requestContext{
+		requestID:       reqCtx.RequestID,
+		sourceIP:        reqCtx.HTTP.SourceIP,
+		userAgent:       reqCtx.HTTP.UserAgent,
+		domainName:      reqCtx.DomainName,
+		integrationType: LambdaFunctionURLIntegration,
+		requestTime:     epochMilliTime(reqCtx.TimeEpoch),
+	}
+}
+
+func newSQSRequestContext(m *events.SQSMessage) *requestContext {
+	c := &requestContext{
+		requestID:       m.MessageId,
+		integrationType: SQSIntegration,
+	}
+	if ts, err := strconv.ParseInt(m.Attributes["SentTimestamp"], 10, 64); err == nil {
+		c.requestTime = epochMilliTime(ts)
+	}
+	return c
+}
+
+func newSNSRequestContext(e *events.SNSEventRecord) *requestContext {
+	return &requestContext{
+		requestID:       e.SNS.MessageID,
+		integrationType: SNSIntegration,
+		requestTime:     e.SNS.Timestamp,
+	}
+}
+
+func newEventBridgeRequestContext(e *events.EventBridgeEvent) *requestContext {
+	return &requestContext{
+		requestID:       e.ID,
+		integrationType: EventBridgeIntegration,
+		requestTime:     e.Time,
+	}
+}
@@ -0,0 +1,149 @@
+package aws
+
+import (
+	"context"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRequestContext(t *testing.T) {
+	requestTime := time.UnixMilli(1700000000000)
+
+	restEvent := &events.APIGatewayProxyRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/echo",
+		RequestContext: events.APIGatewayProxyRequestContext{
+			RequestID:  "rest-id",
+			Stage:      "prod",
+			DomainName: "abc.execute-api.ap-northeast-1.amazonaws.com",
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  "192.0.2.1",
+				UserAgent: "test-agent",
+			},
+			Authorizer: map[string]interface{}{
+				"claims": map[string]interface{}{"sub": "user"},
+			},
+			RequestTimeEpoch: requestTime.UnixMilli(),
+		},
+	}
+
+	httpEvent := &events.APIGatewayV2HTTPRequest{
+		RawPath: "/echo",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RequestID:  "http-id",
+			Stage:      "$default",
+			DomainName: "abc.execute-api.ap-northeast-1.amazonaws.com",
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    http.MethodGet,
+				SourceIP:  "192.0.2.1",
+				UserAgent: "test-agent",
+			},
+			Authorizer: &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+				JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{
+					Claims: map[string]string{"sub": "user"},
+				},
+			},
+			TimeEpoch: requestTime.UnixMilli(),
+		},
+	}
+
+	urlEvent := *httpEvent
+	urlEvent.RequestContext.RequestID = "url-id"
+	urlEvent.RequestContext.DomainName = "abc.lambda-url.ap-northeast-1.on.aws"
+	urlEvent.RequestContext.Authorizer = nil
+
+	albEvent := &events.ALBTargetGroupRequest{
+		HTTPMethod: http.MethodGet,
+		Path:       "/echo",
+		Headers: map[string]string{
+			"host":            "example.com",
+			"user-agent":      "test-agent",
+			"x-forwarded-for": "198.51.100.1, 192.0.2.1",
+		},
+	}
+
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "lambda-id"})
+
+	cases := []struct {
+		name      string
+		request   func() (*http.Request, error)
+		requestID string
+		stage     string
+		domain    string
+		typ       LambdaIntegrationType
+		claims    map[string]interface{}
+		time      time.Time
+	}{
+		{
+			name: "rest_api",
+			request: func() (*http.Request, error) {
+				r, _, err := NewRESTAPIRequest(ctx, restEvent)
+				return r, err
+			},
+			requestID: "rest-id",
+			stage:     "prod",
+			domain:    restEvent.RequestContext.DomainName,
+			typ:       APIGatewayRESTIntegration,
+			claims:    map[string]interface{}{"sub": "user"},
+			time:      requestTime,
+		},
+		{
+			name: "http_api",
+			request: func() (*http.Request, error) {
+				return NewHTTPAPIRequest(ctx, httpEvent)
+			},
+			requestID: "http-id",
+			stage:     "$default",
+			domain:    httpEvent.RequestContext.DomainName,
+			typ:       APIGatewayHTTPIntegration,
+			claims:    map[string]interface{}{"sub": "user"},
+			time:      requestTime,
+		},
+		{
+			name: "function_url",
+			request: func() (*http.Request, error) {
+				return NewHTTPAPIRequest(ctx, &urlEvent)
+			},
+			requestID: "url-id",
+			domain:    urlEvent.RequestContext.DomainName,
+			typ:       LambdaFunctionURLIntegration,
+			time:      requestTime,
+		},
+		{
+			name: "alb_target_group",
+			request: func() (*http.Request, error) {
+				r, _, err := NewALBTargetGroupRequest(ctx, albEvent)
+				return r, err
+			},
+			requestID: "lambda-id",
+			domain:    "example.com",
+			typ:       ALBTargetGroupIntegration,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			r, err := c.request()
+			assert.NoError(t, err)
+
+			var rc types.RequestContext
+			rc, ok := GetRequestContext(r.Context())
+			if !ok {
+				t.Fatalf("request context not found")
+			}
+			assert.Equal(t, c.requestID, rc.RequestID())
+			assert.Equal(t, c.stage, rc.Stage())
+			assert.Equal(t, "192.0.2.1", rc.SourceIP())
+			assert.Equal(t, "test-agent", rc.UserAgent())
+			assert.Equal(t, c.domain, rc.DomainName())
+			assert.Equal(t, c.typ.String(), rc.IntegrationType())
+			assert.Equal(t, c.claims, rc.AuthorizerClaims())
+			assert.True(t, c.time.Equal(rc.RequestTime()))
+		})
+	}
+}
@@ -1,18 +1,41 @@
 package aws
 
 import (
+	"bufio"
 	"bytes"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net"
 	"net/http"
+	"time"
 )
 
+// ResponseWriter http.ResponseWriter buffering the whole response, that is converted into the Lambda response
+// after the handler finished.
+//
+// It supports http.ResponseController with the following semantics.
+//   - Flush writes the header, and the body is kept buffered, since the response can not be sent until the handler finishes.
+//   - SetWriteDeadline is bounded by the deadline of the invocation, and Write after the deadline returns os.ErrDeadlineExceeded.
+//   - SetReadDeadline and EnableFullDuplex are no-op, since the request body is already in memory.
+//   - Hijack returns http.ErrNotSupported.
+//
+// Trailers are sent as headers, since the headers are sent after the handler finished.
 type ResponseWriter struct {
 	status      int
 	headers     http.Header
 	buf         bytes.Buffer
 	wroteHeader bool
 	closeCh     chan bool
+	classifier  *utils.ContentClassifier
+	deadline    writeDeadline
 }
 
+var (
+	_ http.Flusher  = (*ResponseWriter)(nil)
+	_ http.Hijacker = (*ResponseWriter)(nil)
+	_ io.ReaderFrom = (*ResponseWriter)(nil)
+)
+
 func NewResponseWriter() *ResponseWriter {
 	return &ResponseWriter{
 		headers: map[string][]string{},
@@ -28,9 +51,53 @@ func (r *ResponseWriter) Write(i []byte) (int, error) {
 	if !r.wroteHeader {
 		r.WriteHeader(http.StatusOK)
 	}
+	if err := r.deadline.check(); err != nil {
+		return 0, err
+	}
 	return r.buf.Write(i)
 }
 
+// ReadFrom reads the body from src into the buffer.
+func (r *ResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	if err := r.deadline.check(); err != nil {
+		return 0, err
+	}
+	return r.buf.ReadFrom(src)
+}
+
+// Flush writes the header if not written yet. The body is kept buffered.
+func (r *ResponseWriter) Flush() {
+	_ = r.FlushError()
+}
+
+// FlushError sends the header if not written yet, and returns nil.
+func (r *ResponseWriter) FlushError() error {
+	if !r.wroteHeader {
+		r.WriteHeader(http.StatusOK)
+	}
+	return nil
+}
+
+func (r *ResponseWriter) SetWriteDeadline(t time.Time) error {
+	r.deadline.set(t)
+	return nil
+}
+
+func (r *ResponseWriter) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (r *ResponseWriter) EnableFullDuplex() error {
+	return nil
+}
+
+func (r *ResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
 func (r *ResponseWriter) WriteHeader(statusCode int) {
 	if r.wroteHeader {
 		return
@@ -45,6 +112,28 @@ func (r *ResponseWriter) WriteHeader(statusCode int) {
 	r.wroteHeader = true
 }
 
+// reset Discard the header and the body written so far.
+func (r *ResponseWriter) reset() {
+	r.status = 0
+	r.headers = http.Header{}
+	r.buf.Reset()
+	r.wroteHeader = false
+}
+
+// isBinary reports whether the body must be base64 encoded, with the classifier of the handler.
+func (r *ResponseWriter) isBinary() bool {
+	if r.classifier != nil {
+		return r.classifier.IsBinaryContent(r.headers)
+	}
+	return utils.IsBinaryContent(r.headers)
+}
+
+// succeeded reports whether the handler responded with 2xx status.
+// The status is regarded as 200 if the handler did not write anything.
+func (r *ResponseWriter) succeeded() bool {
+	return r.status == 0 || (200 <= r.status && r.status < 300)
+}
+
 func (r *ResponseWriter) CloseNotify() <-chan bool {
 	return r.closeCh
 }
@@ -0,0 +1,228 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+type recordingManagementAPI struct {
+	messages  []string
+	deadlines []time.Time
+	deleted   []string
+	err       error
+}
+
+func (r *recordingManagementAPI) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	if r.err != nil {
+		return r.err
+	}
+	t, _ := ctx.Deadline()
+	r.deadlines = append(r.deadlines, t)
+	r.messages = append(r.messages, string(data))
+	return nil
+}
+
+func (r *recordingManagementAPI) DeleteConnection(ctx context.Context, connectionID string) error {
+	r.deleted = append(r.deleted, connectionID)
+	return nil
+}
+
+func (r *recordingManagementAPI) GetConnection(ctx context.Context, connectionID string) (*ConnectionInfo, error) {
+	return &ConnectionInfo{SourceIP: "192.0.2.1"}, nil
+}
+
+func TestResponseWriter_ResponseController(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/controller", func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		w.Header().Set("Trailer", "X-Checksum")
+		assert.NoError(t, rc.Flush())
+		assert.NoError(t, rc.SetReadDeadline(time.Now().Add(time.Second)))
+		assert.NoError(t, rc.EnableFullDuplex())
+		_, _, err := rc.Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+
+		n, err := io.Copy(w, strings.NewReader("hello"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(5), n)
+
+		w.Header().Set("X-Checksum", "abc")
+		w.Header().Set(http.TrailerPrefix+"X-Extra", "def")
+	})
+	mux.HandleFunc("/deadline", func(w http.ResponseWriter, r *http.Request) {
+		rc := http.NewResponseController(w)
+		assert.NoError(t, rc.SetWriteDeadline(time.Now().Add(-time.Second)))
+		_, err := w.Write([]byte("late"))
+		assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+
+		// Zero value resets to the deadline of the invocation.
+		assert.NoError(t, rc.SetWriteDeadline(time.Time{}))
+		_, err = w.Write([]byte("ok"))
+		assert.NoError(t, err)
+	})
+	h := NewLambdaHandler(mux)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	res, err := h.InvokeHTTPAPI(ctx, &events.APIGatewayV2HTTPRequest{
+		RawPath: "/controller",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", res.Body)
+	assert.Equal(t, "abc", res.Headers["X-Checksum"])
+	assert.Equal(t, "def", res.Headers["X-Extra"])
+	_, ok := res.Headers["Trailer"]
+	assert.False(t, ok)
+
+	res, err = h.InvokeHTTPAPI(ctx, &events.APIGatewayV2HTTPRequest{
+		RawPath: "/deadline",
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{Method: http.MethodGet},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", res.Body)
+}
+
+func TestStreamingResponseWriter_ResponseController(t *testing.T) {
+	w := NewStreamingResponseWriter()
+	deadline := time.Now().Add(time.Minute)
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	w.deadline.init(ctx)
+
+	go func() {
+		defer w.Done()
+		rc := http.NewResponseController(w)
+		w.Header().Set(http.TrailerPrefix+"X-Checksum", "abc")
+		assert.NoError(t, rc.Flush())
+
+		// Deadline beyond the invocation is bounded.
+		assert.NoError(t, rc.SetWriteDeadline(deadline.Add(time.Hour)))
+		assert.Equal(t, deadline, w.deadline.get())
+
+		_, err := io.Copy(w, strings.NewReader("streamed"))
+		assert.NoError(t, err)
+
+		_, _, err = rc.Hijack()
+		assert.ErrorIs(t, err, http.ErrNotSupported)
+	}()
+
+	res, err := FunctionURLStreamingResponse(w)
+	assert.NoError(t, err)
+	_, ok := res.Headers["X-Checksum"]
+	assert.False(t, ok)
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed", string(body))
+}
+
+func TestWebsocketResponseWriter_ResponseController(t *testing.T) {
+	client := &recordingManagementAPI{}
+	w := NewWebsocketResponseWriter(context.Background(), client, &events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{ConnectionID: "conn"},
+	})
+	rc := http.NewResponseController(w)
+
+	assert.NoError(t, rc.Flush())
+	_, _, err := rc.Hijack()
+	assert.ErrorIs(t, err, http.ErrNotSupported)
+
+	// ReadFrom posts the whole body as a single message.
+	_, err = io.Copy(w, struct{ io.Reader }{io.MultiReader(strings.NewReader("a"), strings.NewReader("b"))})
+	assert.NoError(t, err)
+
+	deadline := time.Now().Add(time.Minute)
+	assert.NoError(t, rc.SetWriteDeadline(deadline))
+	_, err = w.Write([]byte("c"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"ab", "c"}, client.messages)
+	assert.True(t, client.deadlines[0].IsZero())
+	assert.Equal(t, deadline, client.deadlines[1])
+}
+
+func TestWebsocketResponseWriter_Buffered(t *testing.T) {
+	request := &events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{ConnectionID: "conn"},
+	}
+
+	t.Run("single message", func(t *testing.T) {
+		client := &recordingManagementAPI{}
+		w := NewBufferedWebsocketResponseWriter(context.Background(), client, request)
+		assert.NoError(t, json.NewEncoder(w).Encode(map[string]string{"message": "hello"}))
+		for i := 0; i < 3; i++ {
+			fmt.Fprintf(w, "%d,", i)
+		}
+		assert.Empty(t, client.messages)
+
+		assert.NoError(t, http.NewResponseController(w).Flush())
+		fmt.Fprint(w, "last")
+
+		res, err := WebsocketResponse(w, false)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, []string{"{\"message\":\"hello\"}\n0,1,2,", "last"}, client.messages)
+	})
+
+	t.Run("split", func(t *testing.T) {
+		client := &recordingManagementAPI{}
+		w := NewBufferedWebsocketResponseWriter(context.Background(), client, request)
+		body := strings.Repeat("あ", WebsocketMaxMessageSize/2)
+		_, err := io.Copy(w, strings.NewReader(body))
+		assert.NoError(t, err)
+
+		_, err = WebsocketResponse(w, false)
+		assert.NoError(t, err)
+		assert.Len(t, client.messages, 2)
+		for _, m := range client.messages {
+			assert.LessOrEqual(t, len(m), WebsocketMaxMessageSize)
+			assert.True(t, utf8.ValidString(m))
+		}
+		assert.Equal(t, body, strings.Join(client.messages, ""))
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		errGone := fmt.Errorf("%w: GoneException", ErrConnectionGone)
+		client := &recordingManagementAPI{err: errGone}
+		w := NewBufferedWebsocketResponseWriter(context.Background(), client, request)
+		fmt.Fprint(w, "hello")
+		assert.ErrorIs(t, http.NewResponseController(w).Flush(), ErrConnectionGone)
+		_, err := w.Write([]byte("again"))
+		assert.ErrorIs(t, err, ErrConnectionGone)
+
+		res, err := WebsocketResponse(w, false)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestLambdaHandler_BufferedWebsocketPanic(t *testing.T) {
+	client := &recordingManagementAPI{}
+	h := NewLambdaHandlerWithOption(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("partial"))
+		panic(errors.New("boom"))
+	}), []interface{}{WithBufferedWebsocketResponse(), WithAPIGatewayManagementAPI(client)})
+
+	res, err := h.InvokeWebsocketAPI(context.Background(), &events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{RouteKey: "$default", ConnectionID: "conn"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	assert.Empty(t, client.messages)
+}
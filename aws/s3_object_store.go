@@ -0,0 +1,84 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"path"
+	"time"
+)
+
+// S3PutObjectAPI Subset of s3.Client used by S3ObjectStore.
+type S3PutObjectAPI interface {
+	PutObject(ctx context.Context, params *s3.PutObjectInput, optFns ...func(*s3.Options)) (*s3.PutObjectOutput, error)
+}
+
+// S3PresignGetObjectAPI Subset of s3.PresignClient used by S3ObjectStore.
+type S3PresignGetObjectAPI interface {
+	PresignGetObject(ctx context.Context, params *s3.GetObjectInput, optFns ...func(*s3.PresignOptions)) (*v4.PresignedHTTPRequest, error)
+}
+
+// S3ObjectStore ObjectStore implementation with Amazon S3 or S3 compatible storage.
+type S3ObjectStore struct {
+	client  S3PutObjectAPI
+	presign S3PresignGetObjectAPI
+	bucket  string
+	prefix  string
+}
+
+// NewS3ObjectStore creates a new S3ObjectStore storing objects under the prefix of the bucket.
+// The optFns can be used for S3 compatible storage, such as setting BaseEndpoint and UsePathStyle.
+func NewS3ObjectStore(conf aws.Config, bucket, prefix string, optFns ...func(*s3.Options)) *S3ObjectStore {
+	client := s3.NewFromConfig(conf, optFns...)
+	return NewS3ObjectStoreWithClient(client, s3.NewPresignClient(client), bucket, prefix)
+}
+
+// NewS3ObjectStoreWithClient creates a new S3ObjectStore with the clients.
+func NewS3ObjectStoreWithClient(client S3PutObjectAPI, presign S3PresignGetObjectAPI, bucket, prefix string) *S3ObjectStore {
+	return &S3ObjectStore{
+		client:  client,
+		presign: presign,
+		bucket:  bucket,
+		prefix:  prefix,
+	}
+}
+
+func (s *S3ObjectStore) key(key string) string {
+	if s.prefix == "" {
+		return key
+	}
+	return path.Join(s.prefix, key)
+}
+
+func (s *S3ObjectStore) PutObject(ctx context.Context, key, contentType, contentEncoding string, body []byte) error {
+	input := &s3.PutObjectInput{
+		Bucket:        aws.String(s.bucket),
+		Key:           aws.String(s.key(key)),
+		Body:          bytes.NewReader(body),
+		ContentLength: aws.Int64(int64(len(body))),
+	}
+	if contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	if contentEncoding != "" {
+		input.ContentEncoding = aws.String(contentEncoding)
+	}
+	if _, err := s.client.PutObject(ctx, input); err != nil {
+		return fmt.Errorf("s3: put object: %w", err)
+	}
+	return nil
+}
+
+func (s *S3ObjectStore) PresignGetObject(ctx context.Context, key string, expires time.Duration) (string, error) {
+	req, err := s.presign.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(key)),
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("s3: presign get object: %w", err)
+	}
+	return req.URL, nil
+}
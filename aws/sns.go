@@ -0,0 +1,104 @@
+/*
+Package aws provides an implementation using aws-sdk-go.
+
+Lambda event type compatibility layer for Amazon SNS event source.
+
+See lambda event detail:
+https://docs.aws.amazon.com/lambda/latest/dg/with-sns.html
+*/
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultSNSEventPathTemplate Default path template of SNS notifications.
+// Available parameters are {topic}, {region} and {account}, that are parsed from the topic ARN.
+const DefaultSNSEventPathTemplate = "/events/sns/{topic}"
+
+const (
+	HTTPHeaderSNSMessageID              = "X-Amz-Sns-Message-Id"
+	HTTPHeaderSNSMessageType            = "X-Amz-Sns-Message-Type"
+	HTTPHeaderSNSTopicARN               = "X-Amz-Sns-Topic-Arn"
+	HTTPHeaderSNSSubscriptionARN        = "X-Amz-Sns-Subscription-Arn"
+	HTTPHeaderSNSSubject                = "X-Amz-Sns-Subject"
+	HTTPHeaderSNSTimestamp              = "X-Amz-Sns-Timestamp"
+	HTTPHeaderSNSMessageAttributePrefix = "X-Amz-Sns-Message-Attribute-"
+)
+
+// NewSNSRequest SNS notification to http.Request converter.
+// The request path is built from pathTemplate with the topic ARN.
+func NewSNSRequest(ctx context.Context, e *events.SNSEventRecord, pathTemplate string) (r *http.Request, err error) {
+	var (
+		body   = bytes.NewBufferString(e.SNS.Message)
+		header = make(http.Header)
+	)
+
+	for k, v := range e.SNS.MessageAttributes {
+		// Message attribute is delivered as {"Type": "String", "Value": "..."}
+		if attr, ok := v.(map[string]interface{}); ok {
+			header.Set(HTTPHeaderSNSMessageAttributePrefix+k, fmt.Sprintf("%v", attr["Value"]))
+		}
+	}
+
+	header.Set(HTTPHeaderSNSMessageID, e.SNS.MessageID)
+	header.Set(HTTPHeaderSNSMessageType, e.SNS.Type)
+	header.Set(HTTPHeaderSNSTopicARN, e.SNS.TopicArn)
+	header.Set(HTTPHeaderSNSSubscriptionARN, e.EventSubscriptionArn)
+	if e.SNS.Subject != "" {
+		header.Set(HTTPHeaderSNSSubject, e.SNS.Subject)
+	}
+	if !e.SNS.Timestamp.IsZero() {
+		header.Set(HTTPHeaderSNSTimestamp, e.SNS.Timestamp.Format(time.RFC3339Nano))
+	}
+
+	if json.Valid(body.Bytes()) {
+		header.Set(types.HTTPHeaderContentType, "application/json")
+	} else {
+		header.Set(types.HTTPHeaderContentType, http.DetectContentType(body.Bytes()))
+	}
+	header.Set(types.HTTPHeaderContentLength, strconv.Itoa(body.Len()))
+
+	arn := parseARN(e.SNS.TopicArn)
+	eventPath := ExpandPathParameters(pathTemplate, map[string]string{
+		"topic":   url.PathEscape(arn.Resource),
+		"region":  url.PathEscape(arn.Region),
+		"account": url.PathEscape(arn.Account),
+	})
+
+	// build raw request URL
+	rawURL := "http://localhost/" + strings.TrimLeft(eventPath, "/")
+
+	r, err = http.NewRequestWithContext(ctx, http.MethodPost, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("sns: new request: %w", err)
+	}
+
+	r.Header = header
+	r.RemoteAddr = "127.0.0.1"
+	r.RequestURI = r.URL.RequestURI()
+	r.Host = r.URL.Host
+
+	if r.Header.Get(types.HTTPHeaderXRayTraceIDKey) == "" {
+		if traceID := ctx.Value(types.AWSXRayTraceIDContextKey); traceID != nil {
+			r.Header.Set(types.HTTPHeaderXRayTraceIDKey, fmt.Sprintf("%v", traceID))
+		}
+	}
+
+	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), e))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newSNSRequestContext(e)))
+	r = withRequestLogger(r, "")
+
+	return
+}
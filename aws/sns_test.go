@@ -0,0 +1,107 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestLambdaHandler_InvokeSNS(t *testing.T) {
+	var called bool
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events/sns/orders", func(writer http.ResponseWriter, request *http.Request) {
+		called = true
+
+		raw, ok := utils.RawRequestValue(request.Context())
+		assert.True(t, ok)
+		_, ok = raw.(*events.SNSEventRecord)
+		assert.True(t, ok)
+
+		assert.Equal(t, "message-id", request.Header.Get(HTTPHeaderSNSMessageID))
+		assert.Equal(t, "arn:aws:sns:ap-northeast-1:123456789012:orders", request.Header.Get(HTTPHeaderSNSTopicARN))
+		assert.Equal(t, "created", request.Header.Get(HTTPHeaderSNSSubject))
+		assert.Equal(t, "tenant-a", request.Header.Get(HTTPHeaderSNSMessageAttributePrefix+"Tenant"))
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(request.Body)
+		assert.Equal(t, `{"id":1}`, string(body))
+
+		writer.WriteHeader(http.StatusNoContent)
+	})
+
+	payload := []byte(`{
+  "Records": [
+    {
+      "EventVersion": "1.0",
+      "EventSubscriptionArn": "arn:aws:sns:ap-northeast-1:123456789012:orders:subscription",
+      "EventSource": "aws:sns",
+      "Sns": {
+        "Type": "Notification",
+        "MessageId": "message-id",
+        "TopicArn": "arn:aws:sns:ap-northeast-1:123456789012:orders",
+        "Subject": "created",
+        "Message": "{\"id\":1}",
+        "Timestamp": "2024-01-01T00:00:00.000Z",
+        "MessageAttributes": {
+          "Tenant": {"Type": "String", "Value": "tenant-a"}
+        }
+      }
+    }
+  ]
+}`)
+
+	h := NewLambdaHandler(mux)
+	_, err := h.Invoke(context.Background(), payload)
+	assert.NoError(t, err)
+	assert.True(t, called)
+
+	h = NewLambdaHandlerWithOption(mux, []interface{}{WithSNSEventPathTemplate("/unknown/{topic}")})
+	_, err = h.Invoke(context.Background(), payload)
+	assert.Error(t, err)
+}
+
+func TestLambdaHandler_InvokeEventBridge(t *testing.T) {
+	var called bool
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hooks/aws.s3/Object%20Created", func(writer http.ResponseWriter, request *http.Request) {
+		called = true
+
+		raw, ok := utils.RawRequestValue(request.Context())
+		assert.True(t, ok)
+		_, ok = raw.(*events.EventBridgeEvent)
+		assert.True(t, ok)
+
+		assert.Equal(t, "event-id", request.Header.Get(HTTPHeaderEventBridgeID))
+		assert.Equal(t, "aws.s3", request.Header.Get(HTTPHeaderEventBridgeSource))
+		assert.Equal(t, "Object Created", request.Header.Get(HTTPHeaderEventBridgeDetailType))
+		assert.Equal(t, "arn:aws:s3:::bucket", request.Header.Get(HTTPHeaderEventBridgeResources))
+
+		var detail map[string]string
+		assert.NoError(t, json.NewDecoder(request.Body).Decode(&detail))
+		assert.Equal(t, "bucket", detail["bucket"])
+	})
+
+	payload := []byte(`{
+  "version": "0",
+  "id": "event-id",
+  "detail-type": "Object Created",
+  "source": "aws.s3",
+  "account": "123456789012",
+  "time": "2024-01-01T00:00:00Z",
+  "region": "ap-northeast-1",
+  "resources": ["arn:aws:s3:::bucket"],
+  "detail": {"bucket": "bucket"}
+}`)
+
+	h := NewLambdaHandlerWithOption(mux, []interface{}{WithEventBridgeEventPathTemplate("/hooks/{source}/{detail-type}")})
+	_, err := h.Invoke(context.Background(), payload)
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
@@ -0,0 +1,100 @@
+/*
+Package aws provides an implementation using aws-sdk-go.
+
+Lambda event type compatibility layer for Amazon SQS event source.
+
+See lambda event detail:
+https://docs.aws.amazon.com/lambda/latest/dg/with-sqs.html
+*/
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+const DefaultSQSEventPath = "/events/sqs"
+
+const (
+	HTTPHeaderSQSMessageID              = "X-Amz-Sqs-Message-Id"
+	HTTPHeaderSQSEventSourceARN         = "X-Amz-Sqs-Event-Source-Arn"
+	HTTPHeaderSQSAttributePrefix        = "X-Amz-Sqs-Attribute-"
+	HTTPHeaderSQSMessageAttributePrefix = "X-Amz-Sqs-Message-Attribute-"
+)
+
+// NewSQSRequest SQS message to http.Request converter.
+// Message attributes are mapped to the 'X-Amz-Sqs-Message-Attribute-' prefixed headers,
+// and system attributes are mapped to the 'X-Amz-Sqs-Attribute-' prefixed headers.
+func NewSQSRequest(ctx context.Context, m *events.SQSMessage, eventPath string) (r *http.Request, err error) {
+	var (
+		body   = bytes.NewBufferString(m.Body)
+		header = make(http.Header)
+	)
+
+	for k, v := range m.Attributes {
+		header.Set(HTTPHeaderSQSAttributePrefix+k, v)
+	}
+
+	for k, v := range m.MessageAttributes {
+		if v.StringValue != nil {
+			header.Set(HTTPHeaderSQSMessageAttributePrefix+k, *v.StringValue)
+		} else if v.BinaryValue != nil {
+			header.Set(HTTPHeaderSQSMessageAttributePrefix+k, base64.StdEncoding.EncodeToString(v.BinaryValue))
+		}
+	}
+
+	header.Set(HTTPHeaderSQSMessageID, m.MessageId)
+	header.Set(HTTPHeaderSQSEventSourceARN, m.EventSourceARN)
+
+	if ct, ok := m.MessageAttributes[types.HTTPHeaderContentType]; ok && ct.StringValue != nil {
+		header.Set(types.HTTPHeaderContentType, *ct.StringValue)
+	} else if json.Valid(body.Bytes()) {
+		header.Set(types.HTTPHeaderContentType, "application/json")
+	} else {
+		header.Set(types.HTTPHeaderContentType, http.DetectContentType(body.Bytes()))
+	}
+	header.Set(types.HTTPHeaderContentLength, strconv.Itoa(body.Len()))
+
+	// build raw request URL
+	rawURL := "http://localhost/" + strings.TrimLeft(eventPath, "/")
+
+	r, err = http.NewRequestWithContext(ctx, http.MethodPost, rawURL, body)
+	if err != nil {
+		return nil, fmt.Errorf("sqs: new request: %w", err)
+	}
+
+	r.Header = header
+	r.RemoteAddr = "127.0.0.1"
+	r.RequestURI = r.URL.RequestURI()
+	r.Host = r.URL.Host
+
+	if r.Header.Get(types.HTTPHeaderXRayTraceIDKey) == "" {
+		if traceID := ctx.Value(types.AWSXRayTraceIDContextKey); traceID != nil {
+			r.Header.Set(types.HTTPHeaderXRayTraceIDKey, fmt.Sprintf("%v", traceID))
+		}
+	}
+
+	r = r.WithContext(internal.NewRawRequestValueContext(r.Context(), m))
+	r = r.WithContext(internal.NewRequestContextValueContext(r.Context(), newSQSRequestContext(m)))
+	r = withRequestLogger(r, "")
+
+	return
+}
+
+// SQSQueueName returns the queue name part of the queue ARN.
+func SQSQueueName(arn string) string {
+	return parseARN(arn).Resource
+}
+
+func isFIFOQueue(arn string) bool {
+	return strings.HasSuffix(arn, ".fifo")
+}
@@ -0,0 +1,93 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestLambdaHandler_InvokeSQS(t *testing.T) {
+	const (
+		standardQueue = "arn:aws:sqs:ap-northeast-1:123456789012:orders"
+		fifoQueue     = "arn:aws:sqs:ap-northeast-1:123456789012:payments.fifo"
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/orders", func(writer http.ResponseWriter, request *http.Request) {
+		raw, ok := utils.RawRequestValue(request.Context())
+		assert.True(t, ok)
+		m := raw.(*events.SQSMessage)
+
+		assert.Equal(t, http.MethodPost, request.Method)
+		assert.Equal(t, m.MessageId, request.Header.Get(HTTPHeaderSQSMessageID))
+		assert.Equal(t, standardQueue, request.Header.Get(HTTPHeaderSQSEventSourceARN))
+		assert.Equal(t, "1", request.Header.Get(HTTPHeaderSQSAttributePrefix+"ApproximateReceiveCount"))
+		assert.Equal(t, "application/json", request.Header.Get("Content-Type"))
+
+		body, _ := io.ReadAll(request.Body)
+		if string(body) == `{"ok":false}` {
+			writer.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/events/sqs", func(writer http.ResponseWriter, request *http.Request) {
+		assert.Equal(t, "tenant-a", request.Header.Get(HTTPHeaderSQSMessageAttributePrefix+"Tenant"))
+		body, _ := io.ReadAll(request.Body)
+		if string(body) == "ng" {
+			writer.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		writer.WriteHeader(http.StatusAccepted)
+	})
+
+	tenant := "tenant-a"
+	message := func(id, queue, body string) events.SQSMessage {
+		return events.SQSMessage{
+			MessageId:      id,
+			Body:           body,
+			EventSource:    "aws:sqs",
+			EventSourceARN: queue,
+			Attributes: map[string]string{
+				"ApproximateReceiveCount": "1",
+			},
+			MessageAttributes: map[string]events.SQSMessageAttribute{
+				"Tenant": {StringValue: &tenant, DataType: "String"},
+			},
+		}
+	}
+
+	event := events.SQSEvent{
+		Records: []events.SQSMessage{
+			message("1", standardQueue, `{"ok":true}`),
+			message("2", standardQueue, `{"ok":false}`),
+			message("3", fifoQueue, "ok"),
+			message("4", fifoQueue, "ng"),
+			message("5", fifoQueue, "ok"),
+		},
+	}
+
+	b, err := json.Marshal(event)
+	assert.NoError(t, err)
+
+	h := NewLambdaHandlerWithOption(mux, []interface{}{
+		WithSQSQueueEventPath("orders", "/orders"),
+	})
+
+	ret, err := h.Invoke(context.Background(), b)
+	assert.NoError(t, err)
+
+	res, ok := ret.(*events.SQSEventResponse)
+	if !ok {
+		t.Fatalf("unexpected response: %v", ret)
+	}
+
+	assert.Equal(t, []events.SQSBatchItemFailure{
+		{ItemIdentifier: "2"},
+		{ItemIdentifier: "4"},
+		{ItemIdentifier: "5"},
+	}, res.BatchItemFailures)
+}
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StreamingResponseWriter http.ResponseWriter that streams the response body to the Lambda runtime.
+// The header is sent on the first call of WriteHeader, Write or Flush.
+// Changes to the header after that are not sent, and trailers are discarded,
+// since Lambda Function URLs do not deliver trailers to the client.
+//
+// It supports http.ResponseController with the following semantics.
+//   - Flush sends the header. The body is not buffered, so written data is already sent.
+//   - SetWriteDeadline is bounded by the deadline of the invocation, and Write after the deadline returns os.ErrDeadlineExceeded.
+//   - SetReadDeadline and EnableFullDuplex are no-op, since the request body is already in memory.
+//   - Hijack returns http.ErrNotSupported.
+type StreamingResponseWriter struct {
+	status      int
+	headers     http.Header
+	sentHeaders http.Header
+	wroteHeader bool
+	ready       chan struct{}
+	pr          *io.PipeReader
+	pw          *io.PipeWriter
+	closeCh     chan bool
+	once        sync.Once
+	deadline    writeDeadline
+}
+
+var (
+	_ http.Flusher  = (*StreamingResponseWriter)(nil)
+	_ http.Hijacker = (*StreamingResponseWriter)(nil)
+	_ io.ReaderFrom = (*StreamingResponseWriter)(nil)
+)
+
+func NewStreamingResponseWriter() *StreamingResponseWriter {
+	pr, pw := io.Pipe()
+	return &StreamingResponseWriter{
+		headers: map[string][]string{},
+		ready:   make(chan struct{}),
+		pr:      pr,
+		pw:      pw,
+		closeCh: make(chan bool, 1),
+	}
+}
+
+func (w *StreamingResponseWriter) Header() http.Header {
+	return w.headers
+}
+
+func (w *StreamingResponseWriter) Write(i []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := w.deadline.check(); err != nil {
+		return 0, err
+	}
+	return w.pw.Write(i)
+}
+
+// ReadFrom streams the body from src.
+func (w *StreamingResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	// hide ReadFrom of w from io.Copy
+	return io.Copy(struct{ io.Writer }{w}, src)
+}
+
+func (w *StreamingResponseWriter) WriteHeader(statusCode int) {
+	if w.wroteHeader {
+		return
+	}
+
+	w.status = statusCode
+
+	if w.headers.Get("Content-Type") == "" {
+		w.headers.Set("Content-Type", "text/plain; charset=utf8")
+	}
+
+	w.sentHeaders = w.headers.Clone()
+	w.sentHeaders.Del("Trailer")
+	for k := range w.sentHeaders {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			delete(w.sentHeaders, k)
+		}
+	}
+	w.wroteHeader = true
+	close(w.ready)
+}
+
+// Flush sends the header if not sent yet. Body is not buffered, so written data is already sent.
+func (w *StreamingResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+func (w *StreamingResponseWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return nil
+}
+
+func (w *StreamingResponseWriter) SetWriteDeadline(t time.Time) error {
+	w.deadline.set(t)
+	return nil
+}
+
+func (w *StreamingResponseWriter) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (w *StreamingResponseWriter) EnableFullDuplex() error {
+	return nil
+}
+
+func (w *StreamingResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
+func (w *StreamingResponseWriter) CloseNotify() <-chan bool {
+	return w.closeCh
+}
+
+// abort Finish the response with the error, the runtime reports it as the error of the invocation.
+func (w *StreamingResponseWriter) abort(err error) {
+	_ = w.pw.CloseWithError(err)
+}
+
+// Done finishes the response. The handler must not use the writer after Done.
+func (w *StreamingResponseWriter) Done() {
+	w.once.Do(func() {
+		w.Flush()
+		_ = w.pw.Close()
+		w.closeCh <- true
+	})
+}
@@ -0,0 +1,195 @@
+package aws
+
+import (
+	"context"
+	"encoding/hex"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"strings"
+)
+
+// TracerName Name of the tracer of the adaptor.
+const TracerName = "github.com/yacchi/lambda-http-adaptor/aws"
+
+// IntegrationTypeAttributeKey Attribute of the invocation span holding LambdaIntegrationType.
+const IntegrationTypeAttributeKey = attribute.Key("aws.lambda.integration_type")
+
+// tracing OpenTelemetry instrumentation of the invocations. It is disabled when provider is nil.
+type tracing struct {
+	provider   trace.TracerProvider
+	propagator propagation.TextMapPropagator
+}
+
+func (t *tracing) enabled() bool {
+	return t.provider != nil
+}
+
+// start Start the server span of the invocation.
+// The span is a child of the X-Ray trace of the invocation, if the trace header is available.
+func (t *tracing) start(ctx context.Context, integrationType LambdaIntegrationType, coldStart bool) (context.Context, trace.Span) {
+	if sc, ok := xraySpanContext(xrayTraceHeader(ctx)); ok {
+		ctx = trace.ContextWithRemoteSpanContext(ctx, sc)
+	}
+
+	attrs := []attribute.KeyValue{
+		semconv.CloudProviderAWS,
+		faasTrigger(integrationType),
+		semconv.FaaSColdstart(coldStart),
+		IntegrationTypeAttributeKey.String(integrationType.String()),
+	}
+	if lc, ok := lambdacontext.FromContext(ctx); ok && lc.AwsRequestID != "" {
+		attrs = append(attrs, semconv.FaaSInvocationID(lc.AwsRequestID))
+	}
+	name := lambdacontext.FunctionName
+	if name != "" {
+		attrs = append(attrs, semconv.FaaSName(name), semconv.FaaSVersion(lambdacontext.FunctionVersion))
+	} else {
+		name = integrationType.String()
+	}
+
+	return t.provider.Tracer(TracerName).Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attrs...),
+	)
+}
+
+// annotate Record the attributes of the request to the invocation span,
+// and propagate the span to the handler with the headers of the propagator, such as 'traceparent'.
+// The span is named '{method} {route}' only if the route template is known, otherwise '{method}'.
+func (t *tracing) annotate(h http.Handler, req *http.Request) {
+	ctx := req.Context()
+	span := trace.SpanFromContext(ctx)
+	if rc, ok := GetRequestContext(ctx); ok && isHTTPIntegration(rc.IntegrationType()) {
+		span.SetAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.URLPath(req.URL.Path),
+		)
+		if template := routeTemplate(h, req); template != "" {
+			span.SetName(req.Method + " " + template)
+			span.SetAttributes(semconv.HTTPRoute(template))
+		} else {
+			span.SetName(req.Method)
+		}
+	}
+	propagator := t.propagator
+	if propagator == nil {
+		propagator = propagation.TraceContext{}
+	}
+	propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+}
+
+// end Set the status of the span from the result of the invocation, and end it.
+// The span is exported before the invocation returns, since the execution environment may be frozen after that.
+// For the streaming response, the span ends when the body is read to the end.
+func (t *tracing) end(ctx context.Context, span trace.Span, res any, err error) (any, error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	} else if status := responseStatus(res); status != 0 {
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if http.StatusInternalServerError <= status {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+
+	finish := func() {
+		span.End()
+		t.flush(ctx)
+	}
+
+	if stream, ok := res.(*events.LambdaFunctionURLStreamingResponse); ok && err == nil {
+		stream.Body = &inflightReader{r: stream.Body, done: finish}
+		return res, nil
+	}
+
+	finish()
+	return res, err
+}
+
+// flush Export the ended spans if the provider supports ForceFlush, such as the provider of OpenTelemetry SDK.
+func (t *tracing) flush(ctx context.Context) {
+	if f, ok := t.provider.(interface{ ForceFlush(context.Context) error }); ok {
+		if err := f.ForceFlush(context.WithoutCancel(ctx)); err != nil {
+			log.FromContext(ctx).Warn("aws_lambda: flush spans", "error", err)
+		}
+	}
+}
+
+func faasTrigger(t LambdaIntegrationType) attribute.KeyValue {
+	switch t {
+	case SQSIntegration, SNSIntegration, EventBridgeIntegration:
+		return semconv.FaaSTriggerPubsub
+	case UnknownLambdaIntegrationType:
+		return semconv.FaaSTriggerOther
+	default:
+		return semconv.FaaSTriggerHTTP
+	}
+}
+
+func isHTTPIntegration(integrationType string) bool {
+	switch integrationType {
+	case SQSIntegration.String(), SNSIntegration.String(), EventBridgeIntegration.String(), UnknownLambdaIntegrationType.String():
+		return false
+	}
+	return true
+}
+
+func responseStatus(res any) int {
+	switch r := res.(type) {
+	case *events.APIGatewayProxyResponse:
+		return r.StatusCode
+	case *events.APIGatewayV2HTTPResponse:
+		return r.StatusCode
+	case *events.ALBTargetGroupResponse:
+		return r.StatusCode
+	case *events.LambdaFunctionURLStreamingResponse:
+		return r.StatusCode
+	}
+	return 0
+}
+
+// xraySpanContext Convert the X-Ray trace header into the remote span context.
+// The X-Ray trace ID '1-5759e988-bd862e3fe1be46a994272793' is the W3C trace ID '5759e988bd862e3fe1be46a994272793'.
+func xraySpanContext(header string) (trace.SpanContext, bool) {
+	root := xrayTraceField(header, "Root")
+	parent := xrayTraceField(header, "Parent")
+	version, rest, ok := strings.Cut(root, "-")
+	if !ok || version != "1" || parent == "" {
+		return trace.SpanContext{}, false
+	}
+
+	var (
+		traceID trace.TraceID
+		spanID  trace.SpanID
+	)
+	if b, err := hex.DecodeString(strings.ReplaceAll(rest, "-", "")); err != nil || len(b) != len(traceID) {
+		return trace.SpanContext{}, false
+	} else {
+		copy(traceID[:], b)
+	}
+	if b, err := hex.DecodeString(parent); err != nil || len(b) != len(spanID) {
+		return trace.SpanContext{}, false
+	} else {
+		copy(spanID[:], b)
+	}
+
+	var flags trace.TraceFlags
+	if xrayTraceField(header, "Sampled") == "1" {
+		flags = trace.FlagsSampled
+	}
+
+	sc := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: flags,
+		Remote:     true,
+	})
+	return sc, sc.IsValid()
+}
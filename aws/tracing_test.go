@@ -0,0 +1,164 @@
+package aws
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/aws/aws-lambda-go/lambdacontext"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws/awstest"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testXRayTraceHeader = "Root=1-5759e988-bd862e3fe1be46a994272793;Parent=53995c3f42cd8ad8;Sampled=1"
+
+func newTracingTestHandler(t *testing.T, exporter *tracetest.InMemoryExporter, options ...interface{}) *LambdaHandler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/items/", func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		assert.True(t, span.SpanContext().IsValid())
+		w.Header().Set("X-Traceparent", r.Header.Get("Traceparent"))
+		w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/error", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/stream", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("streamed"))
+	})
+
+	// The batcher only exports spans on ForceFlush before the invocation returns.
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	t.Cleanup(func() { _ = tp.Shutdown(context.Background()) })
+
+	return NewLambdaHandlerWithOption(mux, append(options, WithTracerProvider(tp)))
+}
+
+func invokeEvent(t *testing.T, h *LambdaHandler, event any) any {
+	payload, err := json.Marshal(event)
+	assert.NoError(t, err)
+	ctx := lambdacontext.NewContext(context.Background(), &lambdacontext.LambdaContext{AwsRequestID: "request-id"})
+	ctx = context.WithValue(ctx, types.AWSXRayTraceIDContextKey, testXRayTraceHeader)
+	res, err := h.Invoke(ctx, payload)
+	assert.NoError(t, err)
+	return res
+}
+
+func TestLambdaHandler_Tracing(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	h := newTracingTestHandler(t, exporter)
+
+	e, err := awstest.NewRESTAPIRequest(httptest.NewRequest(http.MethodGet, "/items/1", nil),
+		awstest.WithResource("/items/{id}", map[string]string{"id": "1"}))
+	assert.NoError(t, err)
+	res := invokeEvent(t, h, e).(*events.APIGatewayProxyResponse)
+
+	spans := exporter.GetSpans()
+	if !assert.Len(t, spans, 1) {
+		return
+	}
+	span := spans[0]
+	assert.Equal(t, "GET /items/{id}", span.Name)
+	assert.Equal(t, trace.SpanKindServer, span.SpanKind)
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", span.SpanContext.TraceID().String())
+	assert.Equal(t, "53995c3f42cd8ad8", span.Parent.SpanID().String())
+	assert.Contains(t, span.Attributes, semconv.FaaSTriggerHTTP)
+	assert.Contains(t, span.Attributes, semconv.FaaSInvocationID("request-id"))
+	assert.Contains(t, span.Attributes, semconv.HTTPRoute("/items/{id}"))
+	assert.Contains(t, span.Attributes, semconv.HTTPResponseStatusCode(http.StatusOK))
+	assert.Contains(t, span.Attributes, IntegrationTypeAttributeKey.String("rest_api"))
+	assert.Equal(t, codes.Unset, span.Status.Code)
+
+	// The handler receives the span of the invocation with traceparent.
+	assert.Equal(t, "00-5759e988bd862e3fe1be46a994272793-"+span.SpanContext.SpanID().String()+"-01",
+		http.Header(res.MultiValueHeaders).Get("X-Traceparent"))
+
+	exporter.Reset()
+	e, err = awstest.NewRESTAPIRequest(httptest.NewRequest(http.MethodGet, "/error", nil))
+	assert.NoError(t, err)
+	invokeEvent(t, h, e)
+	spans = exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, codes.Error, spans[0].Status.Code)
+		assert.Contains(t, spans[0].Attributes, semconv.FaaSColdstart(false))
+	}
+}
+
+func TestLambdaHandler_TracingRoute(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	h := newTracingTestHandler(t, exporter)
+
+	hasRoute := func(span tracetest.SpanStub) bool {
+		for _, attr := range span.Attributes {
+			if attr.Key == semconv.HTTPRouteKey {
+				return true
+			}
+		}
+		return false
+	}
+
+	// The pattern of http.ServeMux is the route template.
+	e, err := awstest.NewFunctionURLRequest(httptest.NewRequest(http.MethodGet, "/items/123", nil))
+	assert.NoError(t, err)
+	invokeEvent(t, h, e)
+	spans := exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "GET /items/", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, semconv.HTTPRoute("/items/"))
+		assert.Contains(t, spans[0].Attributes, semconv.URLPath("/items/123"))
+	}
+
+	// Without the template, the concrete path is not used as the route.
+	exporter.Reset()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	h = NewLambdaHandlerWithOption(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}), []interface{}{WithTracerProvider(tp)})
+	alb, err := awstest.NewALBTargetGroupRequest(httptest.NewRequest(http.MethodGet, "/items/123", nil))
+	assert.NoError(t, err)
+	invokeEvent(t, h, alb)
+	spans = exporter.GetSpans()
+	if assert.Len(t, spans, 1) {
+		assert.Equal(t, "GET", spans[0].Name)
+		assert.False(t, hasRoute(spans[0]))
+		assert.Contains(t, spans[0].Attributes, semconv.URLPath("/items/123"))
+	}
+}
+
+func TestLambdaHandler_TracingStream(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	h := newTracingTestHandler(t, exporter, WithResponseStream())
+
+	e, err := awstest.NewFunctionURLRequest(httptest.NewRequest(http.MethodGet, "/stream", nil))
+	assert.NoError(t, err)
+	res := invokeEvent(t, h, e).(*events.LambdaFunctionURLStreamingResponse)
+
+	// The span ends when the body is read to the end.
+	assert.Empty(t, exporter.GetSpans())
+	body, err := io.ReadAll(res.Body)
+	assert.NoError(t, err)
+	assert.Equal(t, "streamed", string(body))
+	assert.Len(t, exporter.GetSpans(), 1)
+}
+
+func TestXRaySpanContext(t *testing.T) {
+	sc, ok := xraySpanContext(testXRayTraceHeader)
+	assert.True(t, ok)
+	assert.True(t, sc.IsRemote())
+	assert.True(t, sc.IsSampled())
+	assert.Equal(t, "5759e988bd862e3fe1be46a994272793", sc.TraceID().String())
+
+	_, ok = xraySpanContext("Root=1-5759e988-bd862e3fe1be46a994272793;Sampled=0")
+	assert.False(t, ok)
+	_, ok = xraySpanContext("Root=2-invalid;Parent=53995c3f42cd8ad8")
+	assert.False(t, ok)
+}
@@ -1,5 +1,7 @@
 package aws
 
+import "strings"
+
 func ExpandPathParameters(s string, parameters map[string]string) string {
 	buf := make([]byte, 0, 2*len(s))
 	for j := 0; j < len(s); j++ {
@@ -29,3 +31,26 @@ func ExpandPathParameters(s string, parameters map[string]string) string {
 	}
 	return string(buf)
 }
+
+type arnParts struct {
+	Partition string
+	Service   string
+	Region    string
+	Account   string
+	Resource  string
+}
+
+// parseARN split ARN 'arn:partition:service:region:account-id:resource' into parts.
+func parseARN(arn string) (parts arnParts) {
+	sections := strings.SplitN(arn, ":", 6)
+	if len(sections) != 6 || sections[0] != "arn" {
+		parts.Resource = arn
+		return
+	}
+	parts.Partition = sections[1]
+	parts.Service = sections[2]
+	parts.Region = sections[3]
+	parts.Account = sections[4]
+	parts.Resource = sections[5]
+	return
+}
@@ -1,11 +1,35 @@
 package aws
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net"
 	"net/http"
+	"time"
+	"unicode/utf8"
 )
 
+// WebsocketMaxMessageSize Maximum size of a message posted to the connection.
+// Larger payloads are split into multiple messages.
+const WebsocketMaxMessageSize = 128 * 1024
+
+// WebsocketResponseWriter http.ResponseWriter that posts each Write to the connection as a message.
+//
+// In the buffered mode, writes are accumulated and posted as a single message on Flush or when the handler returns.
+// If posting the buffered message fails, the error is returned by later writes and FlushError,
+// and the response to API Gateway has 500 Internal Server Error status.
+//
+// It supports http.ResponseController with the following semantics.
+//   - Flush posts the buffered message in the buffered mode, otherwise it is a no-op, since each Write is already posted.
+//   - SetWriteDeadline is bounded by the deadline of the invocation, and applied to PostToConnection.
+//   - SetReadDeadline and EnableFullDuplex are no-op, since the request body is already in memory.
+//   - Hijack returns http.ErrNotSupported.
+//
+// Trailers are sent as headers of the response returned to API Gateway.
 type WebsocketResponseWriter struct {
 	ctx         context.Context
 	client      APIGatewayManagementAPI
@@ -14,16 +38,39 @@ type WebsocketResponseWriter struct {
 	headers     http.Header
 	wroteHeader bool
 	closeCh     chan bool
+	classifier  *utils.ContentClassifier
+	deadline    writeDeadline
+	buffered    bool
+	buf         bytes.Buffer
+	// posted Whether any message is posted to the connection.
+	posted bool
+	// err Error of posting the buffered message.
+	err error
 }
 
+var (
+	_ http.Flusher  = (*WebsocketResponseWriter)(nil)
+	_ http.Hijacker = (*WebsocketResponseWriter)(nil)
+	_ io.ReaderFrom = (*WebsocketResponseWriter)(nil)
+)
+
 func NewWebsocketResponseWriter(ctx context.Context, client APIGatewayManagementAPI, request *events.APIGatewayWebsocketProxyRequest) *WebsocketResponseWriter {
-	return &WebsocketResponseWriter{
+	w := &WebsocketResponseWriter{
 		ctx:     ctx,
 		client:  client,
 		req:     request,
 		headers: map[string][]string{},
 		closeCh: make(chan bool, 1),
 	}
+	w.deadline.init(ctx)
+	return w
+}
+
+// NewBufferedWebsocketResponseWriter creates WebsocketResponseWriter in the buffered mode.
+func NewBufferedWebsocketResponseWriter(ctx context.Context, client APIGatewayManagementAPI, request *events.APIGatewayWebsocketProxyRequest) *WebsocketResponseWriter {
+	w := NewWebsocketResponseWriter(ctx, client, request)
+	w.buffered = true
+	return w
 }
 
 func (w *WebsocketResponseWriter) Header() http.Header {
@@ -35,13 +82,125 @@ func (w *WebsocketResponseWriter) Write(i []byte) (int, error) {
 		w.WriteHeader(http.StatusOK)
 	}
 
-	err := w.client.PostToConnection(w.ctx, w.req.RequestContext.ConnectionID, i)
-	if err != nil {
+	if w.buffered {
+		if w.err != nil {
+			return 0, w.err
+		}
+		if err := w.deadline.check(); err != nil {
+			return 0, err
+		}
+		return w.buf.Write(i)
+	}
+
+	if err := w.post(i); err != nil {
 		return 0, err
 	}
 	return len(i), nil
 }
 
+// post Post the data to the connection, split into messages of WebsocketMaxMessageSize.
+func (w *WebsocketResponseWriter) post(data []byte) error {
+	if err := w.deadline.check(); err != nil {
+		return err
+	}
+
+	ctx := w.ctx
+	if t := w.deadline.get(); !t.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, t)
+		defer cancel()
+	}
+
+	for 0 < len(data) {
+		n := messageSize(data)
+		if err := w.client.PostToConnection(ctx, w.req.RequestContext.ConnectionID, data[:n]); err != nil {
+			return err
+		}
+		w.posted = true
+		data = data[n:]
+	}
+	return nil
+}
+
+// messageSize Size of the first message of the data, avoiding to split a UTF-8 character of text messages.
+func messageSize(data []byte) int {
+	if len(data) <= WebsocketMaxMessageSize {
+		return len(data)
+	}
+	for n := WebsocketMaxMessageSize; WebsocketMaxMessageSize-utf8.UTFMax < n; n-- {
+		if utf8.RuneStart(data[n]) {
+			return n
+		}
+	}
+	return WebsocketMaxMessageSize
+}
+
+// ReadFrom reads src until EOF, and posts it as a single message.
+func (w *WebsocketResponseWriter) ReadFrom(src io.Reader) (int64, error) {
+	if w.buffered {
+		if !w.wroteHeader {
+			w.WriteHeader(http.StatusOK)
+		}
+		if w.err != nil {
+			return 0, w.err
+		}
+		return w.buf.ReadFrom(src)
+	}
+
+	b, err := io.ReadAll(src)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) == 0 {
+		return 0, nil
+	}
+	n, err := w.Write(b)
+	return int64(n), err
+}
+
+// Flush writes the header if not written yet, and posts the buffered message in the buffered mode.
+func (w *WebsocketResponseWriter) Flush() {
+	_ = w.FlushError()
+}
+
+func (w *WebsocketResponseWriter) FlushError() error {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.err != nil || w.buf.Len() == 0 {
+		return w.err
+	}
+	w.err = w.post(w.buf.Bytes())
+	w.buf.Reset()
+	return w.err
+}
+
+// discard Discard the buffered message, and set the status unless any message is posted.
+func (w *WebsocketResponseWriter) discard(status int) {
+	w.buf.Reset()
+	if !w.posted {
+		w.status = status
+		w.wroteHeader = true
+	}
+}
+
+func (w *WebsocketResponseWriter) SetWriteDeadline(t time.Time) error {
+	w.deadline.set(t)
+	return nil
+}
+
+func (w *WebsocketResponseWriter) SetReadDeadline(time.Time) error {
+	return nil
+}
+
+func (w *WebsocketResponseWriter) EnableFullDuplex() error {
+	return nil
+}
+
+func (w *WebsocketResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, http.ErrNotSupported
+}
+
 func (w *WebsocketResponseWriter) WriteHeader(statusCode int) {
 	if w.wroteHeader {
 		return
@@ -63,3 +222,11 @@ func (w *WebsocketResponseWriter) CloseNotify() <-chan bool {
 func (w *WebsocketResponseWriter) Done() {
 	w.closeCh <- true
 }
+
+// isBinary reports whether the body must be base64 encoded, with the classifier of the handler.
+func (w *WebsocketResponseWriter) isBinary() bool {
+	if w.classifier != nil {
+		return w.classifier.IsBinaryContent(w.headers)
+	}
+	return utils.IsBinaryContent(w.headers)
+}
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// writeDeadline Write deadline of the response writers, set by http.ResponseController.SetWriteDeadline.
+// The deadline never exceeds the deadline of the Lambda invocation, since the execution environment is frozen after that.
+type writeDeadline struct {
+	mu       sync.Mutex
+	limit    time.Time
+	deadline time.Time
+}
+
+// init Set the deadline of the invocation from the context.
+func (d *writeDeadline) init(ctx context.Context) {
+	if t, ok := ctx.Deadline(); ok {
+		d.mu.Lock()
+		d.limit = t
+		d.deadline = t
+		d.mu.Unlock()
+	}
+}
+
+// set Zero value resets the deadline to the deadline of the invocation.
+func (d *writeDeadline) set(t time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if t.IsZero() || (!d.limit.IsZero() && d.limit.Before(t)) {
+		d.deadline = d.limit
+	} else {
+		d.deadline = t
+	}
+}
+
+func (d *writeDeadline) get() time.Time {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.deadline
+}
+
+// check Returns os.ErrDeadlineExceeded as net.Conn does, if the deadline is exceeded.
+func (d *writeDeadline) check() error {
+	if t := d.get(); !t.IsZero() && !time.Now().Before(t) {
+		return os.ErrDeadlineExceeded
+	}
+	return nil
+}
+
+// foldTrailers Merge the trailers into the headers, for the writers sending the headers after the handler finished.
+// The values of the trailers declared with 'Trailer' header are already in the headers,
+// and the trailers set with http.TrailerPrefix are renamed.
+func foldTrailers(h http.Header) {
+	h.Del("Trailer")
+	for k, v := range h {
+		if strings.HasPrefix(k, http.TrailerPrefix) {
+			h[http.CanonicalHeaderKey(strings.TrimPrefix(k, http.TrailerPrefix))] = v
+			delete(h, k)
+		}
+	}
+}
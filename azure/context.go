@@ -0,0 +1,54 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+)
+
+type contextKey int
+
+const (
+	invocationContextKey contextKey = iota
+)
+
+func newInvocationContext(ctx context.Context, inv *invocation) context.Context {
+	return context.WithValue(ctx, invocationContextKey, inv)
+}
+
+func invocationFromContext(ctx context.Context) (*invocation, bool) {
+	inv, ok := ctx.Value(invocationContextKey).(*invocation)
+	return inv, ok
+}
+
+// GetInvokeRequest Get the invocation payload of the non-HTTP triggers from the request context.
+func GetInvokeRequest(ctx context.Context) (*InvokeRequest, bool) {
+	if v, ok := utils.RawRequestValue(ctx); ok {
+		if r, ok := v.(*InvokeRequest); ok {
+			return r, true
+		}
+	}
+	return nil, false
+}
+
+// SetOutput Set the value of the output binding returned to the Functions host.
+// It returns false when the context is not of the invocation of the non-HTTP triggers.
+func SetOutput(ctx context.Context, name string, value interface{}) bool {
+	inv, ok := invocationFromContext(ctx)
+	if !ok {
+		return false
+	}
+	inv.setOutput(name, value)
+	return true
+}
+
+// AddLog Add the log message returned to the Functions host.
+// It returns false when the context is not of the invocation of the non-HTTP triggers.
+func AddLog(ctx context.Context, format string, args ...interface{}) bool {
+	inv, ok := invocationFromContext(ctx)
+	if !ok {
+		return false
+	}
+	inv.addLog(fmt.Sprintf(format, args...))
+	return true
+}
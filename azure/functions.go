@@ -13,7 +13,24 @@ const (
 )
 
 type FunctionsAdaptor struct {
-	s *http.Server
+	s         *http.Server
+	functions map[string]function
+}
+
+// FunctionsAdaptorOption Option of FunctionsAdaptor.
+type FunctionsAdaptorOption func(f *FunctionsAdaptor)
+
+// WithFunction Handle the invocation of the non-HTTP triggered function, such as queue, timer, blob and Service Bus,
+// as POST request to the path. The body of the request is the data of triggerBinding.
+// The response body is returned as ReturnValue, and the outputs and logs can be set by SetOutput and AddLog.
+// See NewInvocationRequest for details of the conversion.
+func WithFunction(name, path, triggerBinding string) FunctionsAdaptorOption {
+	return func(f *FunctionsAdaptor) {
+		if f.functions == nil {
+			f.functions = map[string]function{}
+		}
+		f.functions[name] = function{path: path, triggerBinding: triggerBinding}
+	}
 }
 
 func (f FunctionsAdaptor) ListenAndServe() error {
@@ -31,12 +48,21 @@ func FunctionsDetector() bool {
 
 func NewAzureFunctionsAdaptor(addr string, h http.Handler, opts []interface{}) types.Adaptor {
 	port := os.Getenv(FunctionsHTTPWorkerPortEnvKey)
-	return &FunctionsAdaptor{
+	f := &FunctionsAdaptor{
 		s: &http.Server{
 			Addr:    ":" + port,
 			Handler: h,
 		},
 	}
+	for _, opt := range opts {
+		if functionsOpt, ok := opt.(FunctionsAdaptorOption); ok {
+			functionsOpt(f)
+		}
+	}
+	if 0 < len(f.functions) {
+		f.s.Handler = &invocationHandler{h: h, functions: f.functions}
+	}
+	return f
 }
 
 func init() {
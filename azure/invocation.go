@@ -0,0 +1,221 @@
+package azure
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+const (
+	HTTPHeaderInvocationID   = "X-Azure-Functions-InvocationId"
+	HTTPHeaderFunctionName   = "X-Azure-Functions-Function-Name"
+	HTTPHeaderMetadataPrefix = "X-Azure-Functions-Metadata-"
+
+	textContentType = "text/plain; charset=utf-8"
+	jsonContentType = "application/json"
+)
+
+// InvokeRequest Invocation payload sent by the Functions host to the custom handler.
+// See https://learn.microsoft.com/azure/azure-functions/functions-custom-handlers#request-payload
+type InvokeRequest struct {
+	Data     map[string]json.RawMessage `json:"Data"`
+	Metadata map[string]json.RawMessage `json:"Metadata"`
+}
+
+// InvokeResponse Response payload returned to the Functions host from the custom handler.
+type InvokeResponse struct {
+	Outputs     map[string]interface{} `json:"Outputs,omitempty"`
+	Logs        []string               `json:"Logs"`
+	ReturnValue interface{}            `json:"ReturnValue,omitempty"`
+}
+
+type function struct {
+	path           string
+	triggerBinding string
+}
+
+// invocationHandler Converts the invocation payload of the non-HTTP triggers into http.Request on the configured paths.
+type invocationHandler struct {
+	h         http.Handler
+	functions map[string]function
+}
+
+func (i *invocationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	f, ok := i.functions[name]
+	if !ok || r.Method != http.MethodPost {
+		i.h.ServeHTTP(w, r)
+		return
+	}
+
+	var invoke InvokeRequest
+	if err := json.NewDecoder(r.Body).Decode(&invoke); err != nil {
+		http.Error(w, fmt.Sprintf("azure: decode invocation: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	inv := &invocation{}
+	req, err := NewInvocationRequest(newInvocationContext(r.Context(), inv), name, &invoke, f.path, f.triggerBinding)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	for k, v := range r.Header {
+		if _, ok := req.Header[k]; !ok {
+			req.Header[k] = v
+		}
+	}
+
+	rw := newResponseWriter()
+	i.h.ServeHTTP(rw, req)
+
+	res := InvokeResponse{
+		Outputs: inv.outputs,
+		Logs:    inv.logs,
+	}
+	if res.Logs == nil {
+		res.Logs = []string{}
+	}
+
+	status := http.StatusOK
+	if rw.status < 200 || 300 <= rw.status {
+		// The Functions host treats non-2xx response as a failure, and retries the invocation depending on the trigger.
+		msg := fmt.Sprintf("azure: %s responded with status %d: %s", f.path, rw.status, rw.buf.String())
+		log.FromContext(r.Context()).Warn(msg)
+		res.Logs = append(res.Logs, msg)
+		status = http.StatusInternalServerError
+	} else {
+		res.ReturnValue = returnValue(rw)
+	}
+
+	w.Header().Set(types.HTTPHeaderContentType, jsonContentType)
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(res)
+}
+
+// NewInvocationRequest Azure Functions invocation payload to http.Request converter.
+// The request body is the data of the trigger binding. When the data is a JSON string, such as queue messages,
+// the body is the unquoted string. If triggerBinding is empty, the body is whole of the Data as JSON.
+// The metadata are set to the headers with HTTPHeaderMetadataPrefix. String values are unquoted,
+// and the other values, such as numbers, booleans and objects, are set as compact JSON.
+func NewInvocationRequest(ctx context.Context, functionName string, invoke *InvokeRequest, path, triggerBinding string) (*http.Request, error) {
+	var (
+		body        []byte
+		contentType = jsonContentType
+	)
+
+	if triggerBinding == "" {
+		b, err := json.Marshal(invoke.Data)
+		if err != nil {
+			return nil, fmt.Errorf("azure: new request: %w", err)
+		}
+		body = b
+	} else {
+		data, ok := invoke.Data[triggerBinding]
+		if !ok {
+			return nil, fmt.Errorf("azure: new request: binding %q not found in the invocation data", triggerBinding)
+		}
+		var s string
+		if err := json.Unmarshal(data, &s); err == nil {
+			body = []byte(s)
+			contentType = textContentType
+		} else {
+			body = data
+		}
+	}
+
+	req, err := http.NewRequestWithContext(internal.NewRawRequestValueContext(ctx, invoke), http.MethodPost, path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("azure: new request: %w", err)
+	}
+
+	req.RequestURI = path
+	req.Header.Set(types.HTTPHeaderContentType, contentType)
+	req.Header.Set(HTTPHeaderFunctionName, functionName)
+	for k, v := range invoke.Metadata {
+		if s, ok := metadataValue(v); ok {
+			req.Header.Set(HTTPHeaderMetadataPrefix+k, s)
+		}
+	}
+
+	return req, nil
+}
+
+// metadataValue Header value of the metadata. Null is not set.
+func metadataValue(v json.RawMessage) (string, bool) {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, v); err != nil || buf.String() == "null" {
+		return "", false
+	}
+	var s string
+	if err := json.Unmarshal(buf.Bytes(), &s); err == nil {
+		return s, true
+	}
+	return buf.String(), true
+}
+
+// returnValue Use the response body as the return value. JSON response is passed through as is.
+func returnValue(rw *responseWriter) interface{} {
+	if rw.buf.Len() == 0 {
+		return nil
+	}
+	b := rw.buf.Bytes()
+	if strings.HasPrefix(rw.header.Get(types.HTTPHeaderContentType), jsonContentType) && json.Valid(b) {
+		return json.RawMessage(b)
+	}
+	return string(b)
+}
+
+// invocation Output bindings and logs set by the handler during the invocation.
+type invocation struct {
+	mu      sync.Mutex
+	outputs map[string]interface{}
+	logs    []string
+}
+
+func (i *invocation) setOutput(name string, value interface{}) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.outputs == nil {
+		i.outputs = map[string]interface{}{}
+	}
+	i.outputs[name] = value
+}
+
+func (i *invocation) addLog(msg string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.logs = append(i.logs, msg)
+}
+
+type responseWriter struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+func newResponseWriter() *responseWriter {
+	return &responseWriter{
+		header: http.Header{},
+		status: http.StatusOK,
+	}
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *responseWriter) WriteHeader(statusCode int) {
+	w.status = statusCode
+}
@@ -0,0 +1,107 @@
+package azure
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestInvocationHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/queue", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "hello", string(body))
+		assert.Equal(t, "QueueTrigger", r.Header.Get(HTTPHeaderFunctionName))
+		assert.Equal(t, "1", r.Header.Get(HTTPHeaderMetadataPrefix+"DequeueCount"))
+		assert.Equal(t, "invocation-id", r.Header.Get(HTTPHeaderInvocationID))
+
+		invoke, ok := GetInvokeRequest(r.Context())
+		assert.True(t, ok)
+		assert.Contains(t, invoke.Data, "myQueueItem")
+
+		assert.True(t, SetOutput(r.Context(), "outQueue", "processed"))
+		assert.True(t, AddLog(r.Context(), "processed %s", body))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"ok":true}`))
+	})
+	mux.HandleFunc("/timer", func(w http.ResponseWriter, r *http.Request) {
+		var data map[string]json.RawMessage
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&data))
+		assert.Contains(t, data, "myTimer")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	mux.HandleFunc("/http", func(w http.ResponseWriter, r *http.Request) {
+		assert.False(t, SetOutput(r.Context(), "out", 1))
+		w.Write([]byte("http"))
+	})
+
+	f := NewAzureFunctionsAdaptor("", mux, []interface{}{
+		WithFunction("QueueTrigger", "/queue", "myQueueItem"),
+		WithFunction("TimerTrigger", "/timer", ""),
+	}).(*FunctionsAdaptor)
+
+	invoke := func(path, payload string) (*httptest.ResponseRecorder, InvokeResponse) {
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(payload))
+		req.Header.Set(HTTPHeaderInvocationID, "invocation-id")
+		w := httptest.NewRecorder()
+		f.s.Handler.ServeHTTP(w, req)
+		var res InvokeResponse
+		if w.Header().Get("Content-Type") == jsonContentType {
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &res))
+		}
+		return w, res
+	}
+
+	t.Run("queue", func(t *testing.T) {
+		w, res := invoke("/QueueTrigger", `{"Data":{"myQueueItem":"hello"},"Metadata":{"DequeueCount":"1","sys":{"MethodName":"QueueTrigger"}}}`)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, map[string]interface{}{"outQueue": "processed"}, res.Outputs)
+		assert.Equal(t, []string{"processed hello"}, res.Logs)
+		assert.Equal(t, map[string]interface{}{"ok": true}, res.ReturnValue)
+	})
+
+	t.Run("failure", func(t *testing.T) {
+		w, res := invoke("/TimerTrigger", `{"Data":{"myTimer":{"IsPastDue":false}},"Metadata":{}}`)
+		assert.Equal(t, http.StatusInternalServerError, w.Code)
+		assert.Len(t, res.Logs, 1)
+		assert.Nil(t, res.ReturnValue)
+	})
+
+	t.Run("unknown binding", func(t *testing.T) {
+		w, _ := invoke("/QueueTrigger", `{"Data":{"other":"1"},"Metadata":{}}`)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("forwarded http request", func(t *testing.T) {
+		w, _ := invoke("/http", ``)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "http", w.Body.String())
+	})
+}
+
+func TestNewInvocationRequest_Metadata(t *testing.T) {
+	var invoke InvokeRequest
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"Data": {"myQueueItem": "hello"},
+		"Metadata": {
+			"DequeueCount": 1,
+			"Id": "message-id",
+			"Expired": false,
+			"sys": {"MethodName": "QueueTrigger", "UtcNow": "2024-01-01T00:00:00Z"},
+			"Empty": null
+		}
+	}`), &invoke))
+
+	req, err := NewInvocationRequest(context.Background(), "QueueTrigger", &invoke, "/queue", "myQueueItem")
+	assert.NoError(t, err)
+	assert.Equal(t, "1", req.Header.Get(HTTPHeaderMetadataPrefix+"DequeueCount"))
+	assert.Equal(t, "message-id", req.Header.Get(HTTPHeaderMetadataPrefix+"Id"))
+	assert.Equal(t, "false", req.Header.Get(HTTPHeaderMetadataPrefix+"Expired"))
+	assert.Equal(t, `{"MethodName":"QueueTrigger","UtcNow":"2024-01-01T00:00:00Z"}`, req.Header.Get(HTTPHeaderMetadataPrefix+"sys"))
+	assert.Empty(t, req.Header.Values(HTTPHeaderMetadataPrefix+"Empty"))
+}
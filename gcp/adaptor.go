@@ -0,0 +1,153 @@
+/*
+Package gcp provides an adaptor for Google Cloud Run and Cloud Functions (2nd gen).
+
+The adaptor runs http.Server on the port given by the PORT environment variable, and
+  - parses X-Cloud-Trace-Context header into the request context,
+  - exposes the project, service and revision of the running instance through GetMetadata,
+  - translates CloudEvents (Eventarc) and Pub/Sub push deliveries into routed HTTP requests.
+*/
+package gcp
+
+import (
+	"context"
+	"github.com/yacchi/lambda-http-adaptor/registry"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"os"
+)
+
+const (
+	// PortEnvKey Port to listen on, set by Cloud Run and Cloud Functions.
+	PortEnvKey = "PORT"
+	// ServiceEnvKey Name of the Cloud Run service.
+	ServiceEnvKey = "K_SERVICE"
+	// RevisionEnvKey Name of the Cloud Run revision.
+	RevisionEnvKey = "K_REVISION"
+	// ConfigurationEnvKey Name of the Cloud Run configuration.
+	ConfigurationEnvKey = "K_CONFIGURATION"
+	// FunctionTargetEnvKey Name of the function to execute, set by Cloud Functions.
+	FunctionTargetEnvKey = "FUNCTION_TARGET"
+	// ProjectEnvKey Optional project id. If not set, it is fetched from the metadata server.
+	ProjectEnvKey = "GOOGLE_CLOUD_PROJECT"
+
+	DefaultPort = "8080"
+)
+
+// Detector Cloud Run and Cloud Functions (2nd gen) always set K_SERVICE, and Cloud Functions also sets FUNCTION_TARGET.
+// PORT alone is not used for the detection, since it is commonly set outside Google Cloud.
+func Detector() bool {
+	if os.Getenv(ServiceEnvKey) != "" {
+		return true
+	}
+	if os.Getenv(FunctionTargetEnvKey) != "" {
+		return true
+	}
+	return false
+}
+
+type Option func(a *Adaptor)
+
+// WithCloudEventPathTemplate Set the path template of CloudEvents. The default is DefaultCloudEventPathTemplate.
+// Available parameters are {type}, {source} and {subject}. Empty template disables the routing.
+func WithCloudEventPathTemplate(template string) Option {
+	return func(a *Adaptor) {
+		a.events.cloudEventPathTemplate = template
+	}
+}
+
+// WithPubSubPushPath Decode Pub/Sub push deliveries to the path, and route them by the Pub/Sub path template.
+// Pub/Sub messages delivered by Eventarc as CloudEvents are always routed by the Pub/Sub path template.
+func WithPubSubPushPath(path string) Option {
+	return func(a *Adaptor) {
+		a.events.pubSubPushPath = path
+	}
+}
+
+// WithPubSubPathTemplate Set the path template of Pub/Sub messages. The default is DefaultPubSubPathTemplate.
+// Available parameters are {subscription} and {project}.
+func WithPubSubPathTemplate(template string) Option {
+	return func(a *Adaptor) {
+		a.events.pubSubPathTemplate = template
+	}
+}
+
+// WithMetadata Use the given metadata instead of reading from the environment variables and the metadata server.
+func WithMetadata(m *Metadata) Option {
+	return func(a *Adaptor) {
+		a.metadata = m
+	}
+}
+
+type Adaptor struct {
+	s        *http.Server
+	h        http.Handler
+	metadata *Metadata
+	events   *eventRouter
+}
+
+func (a *Adaptor) ListenAndServe() error {
+	if a.metadata == nil {
+		a.metadata = LoadMetadata(context.Background())
+	}
+	return a.s.ListenAndServe()
+}
+
+func (a *Adaptor) Shutdown(ctx context.Context) error {
+	return a.s.Shutdown(ctx)
+}
+
+func (a *Adaptor) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if a.metadata != nil {
+		ctx = newMetadataContext(ctx, a.metadata)
+	}
+	if tc, ok := ParseTraceContext(r.Header.Get(HTTPHeaderCloudTraceContext)); ok {
+		ctx = newTraceContext(ctx, tc)
+		if traceParent := tc.TraceParent(); traceParent != "" && r.Header.Get(HTTPHeaderTraceParent) == "" {
+			r.Header.Set(HTTPHeaderTraceParent, traceParent)
+		}
+	}
+	r = r.WithContext(ctx)
+
+	if req, ok, err := a.events.route(r); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if ok {
+		r = req
+	}
+
+	a.h.ServeHTTP(w, r)
+}
+
+func NewAdaptor(addr string, h http.Handler, options []interface{}) types.Adaptor {
+	port := os.Getenv(PortEnvKey)
+	if port == "" {
+		port = DefaultPort
+	}
+
+	a := &Adaptor{
+		h: h,
+		events: &eventRouter{
+			cloudEventPathTemplate: DefaultCloudEventPathTemplate,
+			pubSubPathTemplate:     DefaultPubSubPathTemplate,
+		},
+	}
+
+	for _, opt := range options {
+		if gcpOpt, ok := opt.(Option); ok {
+			gcpOpt(a)
+		}
+	}
+
+	// Cloud Run requires to listen on all interfaces of PORT, so addr is ignored.
+	a.s = &http.Server{
+		Addr:    ":" + port,
+		Handler: a,
+	}
+
+	return a
+}
+
+func init() {
+	registry.Registry.AddAdaptor("gcp", Detector, NewAdaptor)
+}
@@ -0,0 +1,206 @@
+package gcp
+
+import (
+	"context"
+	"encoding/base64"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDetector(t *testing.T) {
+	t.Setenv(ServiceEnvKey, "")
+	t.Setenv(FunctionTargetEnvKey, "")
+	assert.False(t, Detector())
+
+	t.Setenv(ServiceEnvKey, "service")
+	assert.True(t, Detector())
+}
+
+func TestParseTraceContext(t *testing.T) {
+	tc, ok := ParseTraceContext("105445aa7843bc8bf206b12000100000/1;o=1")
+	assert.True(t, ok)
+	assert.Equal(t, "105445aa7843bc8bf206b12000100000", tc.TraceID)
+	assert.True(t, tc.Sampled)
+	assert.Equal(t, "00-105445aa7843bc8bf206b12000100000-0000000000000001-01", tc.TraceParent())
+	assert.Equal(t, "projects/p/traces/105445aa7843bc8bf206b12000100000", tc.Trace("p"))
+
+	_, ok = ParseTraceContext("invalid")
+	assert.False(t, ok)
+	_, ok = ParseTraceContext("00000000000000000000000000000000/1;o=1")
+	assert.False(t, ok)
+
+	// span id 0 is valid for Cloud Trace, but not for traceparent
+	tc, ok = ParseTraceContext("105445aa7843bc8bf206b12000100000/0;o=1")
+	assert.True(t, ok)
+	assert.Empty(t, tc.TraceParent())
+}
+
+func TestLoadMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+		switch r.URL.Path {
+		case "/project/project-id":
+			w.Write([]byte("project"))
+		case "/instance/region":
+			w.Write([]byte("projects/123/regions/asia-northeast1"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	defer func(v string) { metadataServerURL = v }(metadataServerURL)
+	metadataServerURL = server.URL + "/"
+
+	t.Setenv(ProjectEnvKey, "")
+	t.Setenv(ServiceEnvKey, "service")
+	t.Setenv(RevisionEnvKey, "service-00001-abc")
+
+	m := LoadMetadata(context.Background())
+	assert.Equal(t, &Metadata{
+		ProjectID: "project",
+		Region:    "asia-northeast1",
+		Service:   "service",
+		Revision:  "service-00001-abc",
+	}, m)
+}
+
+func TestAdaptor_ServeHTTP(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		m, ok := GetMetadata(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "service", m.Service)
+		tc, ok := GetTraceContext(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "00-105445aa7843bc8bf206b12000100000-0000000000000001-01", r.Header.Get(HTTPHeaderTraceParent))
+		w.Write([]byte(tc.TraceID))
+	})
+	mux.HandleFunc("/events/cloudevents/google.cloud.storage.object.v1.finalized", func(w http.ResponseWriter, r *http.Request) {
+		e, ok := GetCloudEvent(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "event-id", e.ID)
+		assert.Equal(t, "event-id", r.Header.Get("Ce-Id"))
+		assert.Equal(t, "objects/file.txt", r.Header.Get("Ce-Subject"))
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	mux.HandleFunc("/events/pubsub/sub", func(w http.ResponseWriter, r *http.Request) {
+		p, ok := GetPubSubMessage(r.Context())
+		assert.True(t, ok)
+		assert.Equal(t, "projects/p/subscriptions/sub", p.Subscription)
+		assert.Equal(t, "message-id", r.Header.Get(HTTPHeaderPubSubMessageID))
+		assert.Equal(t, "v", r.Header.Get(HTTPHeaderPubSubAttributePrefix+"k"))
+		_, isCloudEvent := GetCloudEvent(r.Context())
+		w.Header().Set("X-Cloud-Event", map[bool]string{true: "1", false: "0"}[isCloudEvent])
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+
+	a := NewAdaptor("", mux, []interface{}{
+		WithMetadata(&Metadata{Service: "service"}),
+		WithPubSubPushPath("/pubsub"),
+	}).(*Adaptor)
+
+	pubsub := `{"message":{"data":"` + base64.StdEncoding.EncodeToString([]byte("hello")) +
+		`","attributes":{"k":"v"},"messageId":"message-id","publishTime":"2024-01-01T00:00:00Z"},"subscription":"projects/p/subscriptions/sub"}`
+
+	cases := []struct {
+		name       string
+		path       string
+		header     map[string]string
+		body       string
+		status     int
+		response   string
+		cloudEvent string
+	}{
+		{
+			name:     "http",
+			path:     "/hello",
+			header:   map[string]string{HTTPHeaderCloudTraceContext: "105445aa7843bc8bf206b12000100000/1;o=1"},
+			status:   http.StatusOK,
+			response: "105445aa7843bc8bf206b12000100000",
+		},
+		{
+			name: "cloudevent binary",
+			path: "/",
+			header: map[string]string{
+				HTTPHeaderCloudTraceContext: "105445aa7843bc8bf206b12000100000/1;o=1",
+				"Ce-Id":                     "event-id",
+				"Ce-Specversion":            "1.0",
+				"Ce-Type":                   "google.cloud.storage.object.v1.finalized",
+				"Ce-Source":                 "//storage.googleapis.com/projects/_/buckets/b",
+				"Ce-Subject":                "objects/file.txt",
+				"Content-Type":              "application/json",
+			},
+			body:     `{"name":"file.txt"}`,
+			status:   http.StatusOK,
+			response: `{"name":"file.txt"}`,
+		},
+		{
+			name:     "cloudevent structured",
+			path:     "/",
+			header:   map[string]string{"Content-Type": "application/cloudevents+json; charset=utf-8"},
+			body:     `{"specversion":"1.0","id":"event-id","type":"google.cloud.storage.object.v1.finalized","source":"//storage.googleapis.com/projects/_/buckets/b","subject":"objects/file.txt","data":{"name":"file.txt"}}`,
+			status:   http.StatusOK,
+			response: `{"name":"file.txt"}`,
+		},
+		{
+			name: "eventarc pubsub",
+			path: "/",
+			header: map[string]string{
+				"Ce-Id":          "event-id",
+				"Ce-Specversion": "1.0",
+				"Ce-Type":        PubSubMessagePublishedType,
+				"Ce-Source":      "//pubsub.googleapis.com/projects/p/topics/t",
+				"Content-Type":   "application/json",
+			},
+			body:       pubsub,
+			status:     http.StatusOK,
+			response:   "hello",
+			cloudEvent: "1",
+		},
+		{
+			name:       "pubsub push",
+			path:       "/pubsub",
+			header:     map[string]string{"Content-Type": "application/json"},
+			body:       pubsub,
+			status:     http.StatusOK,
+			response:   "hello",
+			cloudEvent: "0",
+		},
+		{
+			name:   "invalid pubsub push",
+			path:   "/pubsub",
+			body:   "{",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			method := http.MethodPost
+			if c.body == "" {
+				method = http.MethodGet
+			}
+			r := httptest.NewRequest(method, c.path, strings.NewReader(c.body))
+			for k, v := range c.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			a.ServeHTTP(w, r)
+
+			assert.Equal(t, c.status, w.Code)
+			if c.response != "" {
+				assert.Equal(t, c.response, w.Body.String())
+			}
+			if c.cloudEvent != "" {
+				assert.Equal(t, c.cloudEvent, w.Header().Get("X-Cloud-Event"))
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package gcp
+
+import (
+	"context"
+)
+
+type contextKey int
+
+const (
+	metadataContextKey contextKey = iota
+	traceContextKey
+	cloudEventContextKey
+	pubSubContextKey
+)
+
+func newMetadataContext(ctx context.Context, m *Metadata) context.Context {
+	return context.WithValue(ctx, metadataContextKey, m)
+}
+
+func newTraceContext(ctx context.Context, tc TraceContext) context.Context {
+	return context.WithValue(ctx, traceContextKey, tc)
+}
+
+func newCloudEventContext(ctx context.Context, e *CloudEvent) context.Context {
+	return context.WithValue(ctx, cloudEventContextKey, e)
+}
+
+func newPubSubContext(ctx context.Context, p *PubSubPush) context.Context {
+	return context.WithValue(ctx, pubSubContextKey, p)
+}
+
+// GetMetadata Get the project, service and revision metadata of the running service from the request context.
+func GetMetadata(ctx context.Context) (*Metadata, bool) {
+	m, ok := ctx.Value(metadataContextKey).(*Metadata)
+	return m, ok
+}
+
+// GetTraceContext Get the parsed X-Cloud-Trace-Context from the request context.
+func GetTraceContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey).(TraceContext)
+	return tc, ok
+}
+
+// GetCloudEvent Get the CloudEvent from the context of the routed request.
+func GetCloudEvent(ctx context.Context) (*CloudEvent, bool) {
+	e, ok := ctx.Value(cloudEventContextKey).(*CloudEvent)
+	return e, ok
+}
+
+// GetPubSubMessage Get the Pub/Sub push delivery from the context of the routed request.
+func GetPubSubMessage(ctx context.Context) (*PubSubPush, bool) {
+	p, ok := ctx.Value(pubSubContextKey).(*PubSubPush)
+	return p, ok
+}
@@ -0,0 +1,335 @@
+package gcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCloudEventPathTemplate Default path template of CloudEvents.
+// Available parameters are {type}, {source} and {subject}.
+const DefaultCloudEventPathTemplate = "/events/cloudevents/{type}"
+
+// DefaultPubSubPathTemplate Default path template of Pub/Sub messages.
+// Available parameters are {subscription} and {project}, that are parsed from the subscription name.
+const DefaultPubSubPathTemplate = "/events/pubsub/{subscription}"
+
+// PubSubMessagePublishedType CloudEvent type of Pub/Sub messages delivered by Eventarc.
+const PubSubMessagePublishedType = "google.cloud.pubsub.topic.v1.messagePublished"
+
+const (
+	HTTPHeaderPubSubMessageID        = "X-Goog-Pubsub-Message-Id"
+	HTTPHeaderPubSubSubscription     = "X-Goog-Pubsub-Subscription"
+	HTTPHeaderPubSubPublishTime      = "X-Goog-Pubsub-Publish-Time"
+	HTTPHeaderPubSubOrderingKey      = "X-Goog-Pubsub-Ordering-Key"
+	HTTPHeaderPubSubAttributePrefix  = "X-Goog-Pubsub-Attribute-"
+	cloudEventHeaderPrefix           = "Ce-"
+	cloudEventStructuredContentType  = "application/cloudevents+json"
+	cloudEventSpecVersionHeader      = cloudEventHeaderPrefix + "Specversion"
+	defaultCloudEventDataContentType = "application/json"
+)
+
+// CloudEvent CloudEvents v1.0 event, delivered in binary or structured content mode.
+type CloudEvent struct {
+	ID              string
+	Source          string
+	SpecVersion     string
+	Type            string
+	Subject         string
+	DataContentType string
+	DataSchema      string
+	Time            time.Time
+	// Extensions Extension attributes such as 'traceparent'.
+	Extensions map[string]string
+	Data       []byte
+}
+
+// PubSubPush Payload of Pub/Sub push subscription, that is also the data of Eventarc Pub/Sub events.
+type PubSubPush struct {
+	Message      PubSubMessage `json:"message"`
+	Subscription string        `json:"subscription"`
+}
+
+type PubSubMessage struct {
+	// Data is base64 decoded by encoding/json.
+	Data        []byte            `json:"data"`
+	Attributes  map[string]string `json:"attributes"`
+	MessageID   string            `json:"messageId"`
+	PublishTime time.Time         `json:"publishTime"`
+	OrderingKey string            `json:"orderingKey"`
+}
+
+// ParseCloudEvent Parse CloudEvent from the request. It returns false when the request is not a CloudEvent.
+func ParseCloudEvent(r *http.Request) (*CloudEvent, bool, error) {
+	contentType := r.Header.Get(types.HTTPHeaderContentType)
+
+	if strings.HasPrefix(contentType, cloudEventStructuredContentType) {
+		e, err := parseStructuredCloudEvent(r.Body)
+		if err != nil {
+			return nil, true, err
+		}
+		return e, true, nil
+	}
+
+	if r.Header.Get(cloudEventSpecVersionHeader) == "" {
+		return nil, false, nil
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, true, fmt.Errorf("cloudevents: read body: %w", err)
+	}
+
+	e := &CloudEvent{
+		DataContentType: contentType,
+		Extensions:      map[string]string{},
+		Data:            body,
+	}
+	for k, v := range r.Header {
+		if !strings.HasPrefix(k, cloudEventHeaderPrefix) {
+			continue
+		}
+		e.setAttribute(strings.ToLower(strings.TrimPrefix(k, cloudEventHeaderPrefix)), v[0])
+	}
+	return e, true, nil
+}
+
+func parseStructuredCloudEvent(body io.Reader) (*CloudEvent, error) {
+	var attrs map[string]json.RawMessage
+	if err := json.NewDecoder(body).Decode(&attrs); err != nil {
+		return nil, fmt.Errorf("cloudevents: decode: %w", err)
+	}
+
+	e := &CloudEvent{
+		Extensions: map[string]string{},
+	}
+	for k, v := range attrs {
+		switch k {
+		case "data":
+			e.Data = v
+		case "data_base64":
+			if err := json.Unmarshal(v, &e.Data); err != nil {
+				return nil, fmt.Errorf("cloudevents: decode data_base64: %w", err)
+			}
+		default:
+			var s string
+			if err := json.Unmarshal(v, &s); err != nil {
+				// non-string extension attribute, such as integer and boolean
+				s = string(v)
+			}
+			e.setAttribute(k, s)
+		}
+	}
+
+	if e.DataContentType == "" {
+		e.DataContentType = defaultCloudEventDataContentType
+	}
+	// string data of non-JSON content type is delivered as JSON string
+	if _, isData := attrs["data"]; isData && !strings.Contains(e.DataContentType, "json") {
+		var s string
+		if err := json.Unmarshal(e.Data, &s); err == nil {
+			e.Data = []byte(s)
+		}
+	}
+
+	return e, nil
+}
+
+func (e *CloudEvent) setAttribute(name, value string) {
+	switch name {
+	case "id":
+		e.ID = value
+	case "source":
+		e.Source = value
+	case "specversion":
+		e.SpecVersion = value
+	case "type":
+		e.Type = value
+	case "subject":
+		e.Subject = value
+	case "datacontenttype":
+		e.DataContentType = value
+	case "dataschema":
+		e.DataSchema = value
+	case "time":
+		e.Time, _ = time.Parse(time.RFC3339Nano, value)
+	default:
+		e.Extensions[name] = value
+	}
+}
+
+// header CloudEvent attributes in binary content mode.
+func (e *CloudEvent) header() http.Header {
+	h := http.Header{}
+	set := func(name, value string) {
+		if value != "" {
+			h.Set(cloudEventHeaderPrefix+name, value)
+		}
+	}
+	set("Id", e.ID)
+	set("Source", e.Source)
+	set("Specversion", e.SpecVersion)
+	set("Type", e.Type)
+	set("Subject", e.Subject)
+	set("Dataschema", e.DataSchema)
+	if !e.Time.IsZero() {
+		set("Time", e.Time.Format(time.RFC3339Nano))
+	}
+	for k, v := range e.Extensions {
+		set(k, v)
+	}
+	if e.DataContentType != "" {
+		h.Set(types.HTTPHeaderContentType, e.DataContentType)
+	}
+	return h
+}
+
+// NewCloudEventRequest CloudEvent to http.Request converter.
+// The request is sent in binary content mode, the body is the event data and the attributes are 'Ce-' headers.
+func NewCloudEventRequest(r *http.Request, e *CloudEvent, pathTemplate string) (*http.Request, error) {
+	eventPath := expandPathTemplate(pathTemplate, map[string]string{
+		"type":    e.Type,
+		"source":  e.Source,
+		"subject": e.Subject,
+	})
+
+	req, err := newEventRequest(r, eventPath, e.Data, e.header())
+	if err != nil {
+		return nil, fmt.Errorf("cloudevents: new request: %w", err)
+	}
+
+	ctx := internal.NewRawRequestValueContext(req.Context(), e)
+	return req.WithContext(newCloudEventContext(ctx, e)), nil
+}
+
+// NewPubSubRequest Pub/Sub push delivery to http.Request converter.
+// The body is the decoded message data, and the attributes are set to the headers with HTTPHeaderPubSubAttributePrefix.
+func NewPubSubRequest(r *http.Request, p *PubSubPush, pathTemplate string) (*http.Request, error) {
+	project, subscription := parseSubscription(p.Subscription)
+	eventPath := expandPathTemplate(pathTemplate, map[string]string{
+		"subscription": subscription,
+		"project":      project,
+	})
+
+	header := http.Header{}
+	for k, v := range p.Message.Attributes {
+		header.Set(HTTPHeaderPubSubAttributePrefix+k, v)
+	}
+	header.Set(HTTPHeaderPubSubMessageID, p.Message.MessageID)
+	header.Set(HTTPHeaderPubSubSubscription, p.Subscription)
+	if !p.Message.PublishTime.IsZero() {
+		header.Set(HTTPHeaderPubSubPublishTime, p.Message.PublishTime.Format(time.RFC3339Nano))
+	}
+	if p.Message.OrderingKey != "" {
+		header.Set(HTTPHeaderPubSubOrderingKey, p.Message.OrderingKey)
+	}
+	if json.Valid(p.Message.Data) {
+		header.Set(types.HTTPHeaderContentType, "application/json")
+	} else {
+		header.Set(types.HTTPHeaderContentType, http.DetectContentType(p.Message.Data))
+	}
+
+	req, err := newEventRequest(r, eventPath, p.Message.Data, header)
+	if err != nil {
+		return nil, fmt.Errorf("pubsub: new request: %w", err)
+	}
+
+	ctx := req.Context()
+	if _, ok := GetCloudEvent(ctx); !ok {
+		ctx = internal.NewRawRequestValueContext(ctx, p)
+	}
+	return req.WithContext(newPubSubContext(ctx, p)), nil
+}
+
+func newEventRequest(r *http.Request, eventPath string, body []byte, header http.Header) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(r.Context(), http.MethodPost, eventPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	// keep the headers of the delivery, such as Authorization and traceparent
+	for k, v := range r.Header {
+		if strings.HasPrefix(k, cloudEventHeaderPrefix) {
+			continue
+		}
+		req.Header[k] = v
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set(types.HTTPHeaderContentLength, strconv.Itoa(len(body)))
+
+	req.Host = r.Host
+	req.RemoteAddr = r.RemoteAddr
+	req.RequestURI = req.URL.RequestURI()
+	return req, nil
+}
+
+// parseSubscription Parse 'projects/<project>/subscriptions/<subscription>'.
+func parseSubscription(s string) (project, subscription string) {
+	parts := strings.Split(s, "/")
+	if len(parts) == 4 && parts[0] == "projects" && parts[2] == "subscriptions" {
+		return parts[1], parts[3]
+	}
+	return "", s
+}
+
+func expandPathTemplate(template string, parameters map[string]string) string {
+	oldnew := make([]string, 0, 2*len(parameters))
+	for k, v := range parameters {
+		oldnew = append(oldnew, "{"+k+"}", url.PathEscape(v))
+	}
+	return strings.NewReplacer(oldnew...).Replace(template)
+}
+
+// eventRouter Route CloudEvents and Pub/Sub push deliveries to the paths built from the templates.
+type eventRouter struct {
+	cloudEventPathTemplate string
+	pubSubPushPath         string
+	pubSubPathTemplate     string
+}
+
+func (e *eventRouter) route(r *http.Request) (*http.Request, bool, error) {
+	if r.Method != http.MethodPost {
+		return nil, false, nil
+	}
+
+	if e.cloudEventPathTemplate != "" {
+		ce, ok, err := ParseCloudEvent(r)
+		if err != nil {
+			return nil, true, err
+		}
+		if ok {
+			if ce.Type != PubSubMessagePublishedType {
+				req, err := NewCloudEventRequest(r, ce, e.cloudEventPathTemplate)
+				return req, true, err
+			}
+			var p PubSubPush
+			if err := json.Unmarshal(ce.Data, &p); err != nil {
+				return nil, true, fmt.Errorf("pubsub: decode: %w", err)
+			}
+			ctx := internal.NewRawRequestValueContext(newCloudEventContext(r.Context(), ce), ce)
+			req, err := NewPubSubRequest(r.WithContext(ctx), &p, e.pubSubPathTemplate)
+			return req, true, err
+		}
+	}
+
+	if e.pubSubPushPath != "" && r.URL.Path == e.pubSubPushPath {
+		var p PubSubPush
+		if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+			return nil, true, fmt.Errorf("pubsub: decode: %w", err)
+		}
+		req, err := NewPubSubRequest(r, &p, e.pubSubPathTemplate)
+		return req, true, err
+	}
+
+	return nil, false, nil
+}
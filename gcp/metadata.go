@@ -0,0 +1,85 @@
+package gcp
+
+import (
+	"context"
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// metadataServerURL Base URL of the metadata server, which is available on Cloud Run and Cloud Functions.
+var metadataServerURL = "http://metadata.google.internal/computeMetadata/v1/"
+
+const metadataFetchTimeout = time.Second
+
+// Metadata Information of the running service.
+type Metadata struct {
+	ProjectID string
+	// Region is only available from the metadata server.
+	Region         string
+	Service        string
+	Revision       string
+	Configuration  string
+	FunctionTarget string
+}
+
+// LoadMetadata Load Metadata from the environment variables and the metadata server.
+// The metadata server is only queried for the values not set by the environment variables,
+// and failures are logged and ignored, so that the adaptor also works outside Google Cloud.
+func LoadMetadata(ctx context.Context) *Metadata {
+	m := &Metadata{
+		ProjectID:      os.Getenv(ProjectEnvKey),
+		Service:        os.Getenv(ServiceEnvKey),
+		Revision:       os.Getenv(RevisionEnvKey),
+		Configuration:  os.Getenv(ConfigurationEnvKey),
+		FunctionTarget: os.Getenv(FunctionTargetEnvKey),
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, metadataFetchTimeout)
+	defer cancel()
+
+	if m.ProjectID == "" {
+		if v, err := fetchMetadata(ctx, "project/project-id"); err != nil {
+			log.FromContext(ctx).Warn("gcp: fetch project id", "error", err)
+		} else {
+			m.ProjectID = v
+		}
+	}
+
+	// The region is returned as 'projects/<project-number>/regions/<region>'.
+	if v, err := fetchMetadata(ctx, "instance/region"); err != nil {
+		log.FromContext(ctx).Warn("gcp: fetch region", "error", err)
+	} else {
+		m.Region = v[strings.LastIndex(v, "/")+1:]
+	}
+
+	return m
+}
+
+func fetchMetadata(ctx context.Context, path string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, metadataServerURL+path, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status %d", res.StatusCode)
+	}
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
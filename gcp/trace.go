@@ -0,0 +1,73 @@
+package gcp
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	// HTTPHeaderCloudTraceContext Trace header of Google Cloud, 'TRACE_ID/SPAN_ID;o=OPTIONS'.
+	HTTPHeaderCloudTraceContext = "X-Cloud-Trace-Context"
+	// HTTPHeaderTraceParent W3C Trace Context header.
+	HTTPHeaderTraceParent = "traceparent"
+)
+
+// TraceContext Parsed value of X-Cloud-Trace-Context header.
+type TraceContext struct {
+	// TraceID 32 hex characters.
+	TraceID string
+	// SpanID Decimal span id.
+	SpanID  string
+	Sampled bool
+}
+
+// ParseTraceContext Parse X-Cloud-Trace-Context header value. The trace id must not be all zero.
+func ParseTraceContext(v string) (TraceContext, bool) {
+	traceID, rest, ok := strings.Cut(v, "/")
+	if !ok || len(traceID) != 32 {
+		return TraceContext{}, false
+	}
+	high, err := strconv.ParseUint(traceID[:16], 16, 64)
+	if err != nil {
+		return TraceContext{}, false
+	}
+	low, err := strconv.ParseUint(traceID[16:], 16, 64)
+	if err != nil {
+		return TraceContext{}, false
+	}
+	// all-zero trace id is invalid
+	if high == 0 && low == 0 {
+		return TraceContext{}, false
+	}
+
+	spanID, options, _ := strings.Cut(rest, ";")
+	if _, err := strconv.ParseUint(spanID, 10, 64); err != nil {
+		return TraceContext{}, false
+	}
+
+	return TraceContext{
+		TraceID: strings.ToLower(traceID),
+		SpanID:  spanID,
+		Sampled: options == "o=1",
+	}, true
+}
+
+// Trace Resource name of the trace for structured logging, 'projects/<project>/traces/<trace-id>'.
+func (t TraceContext) Trace(projectID string) string {
+	return "projects/" + projectID + "/traces/" + t.TraceID
+}
+
+// TraceParent Convert into the value of W3C traceparent header.
+// It returns an empty string if the span id is 0, since the parent id of traceparent must not be all zero.
+func (t TraceContext) TraceParent() string {
+	spanID, _ := strconv.ParseUint(t.SpanID, 10, 64)
+	if spanID == 0 {
+		return ""
+	}
+	flags := "00"
+	if t.Sampled {
+		flags = "01"
+	}
+	return fmt.Sprintf("00-%s-%016x-%s", t.TraceID, spanID, flags)
+}
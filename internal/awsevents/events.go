@@ -0,0 +1,489 @@
+// Package awsevents converts http.Request into the events of the AWS Lambda integrations, and the responses back
+// into http.Response. It is shared by the awstest package and the local emulator.
+package awsevents
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"github.com/yacchi/lambda-http-adaptor/utils"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultStage        = "test"
+	DefaultResourcePath = "/{proxy+}"
+	requestTimeFormat   = "02/Jan/2006:15:04:05 -0700"
+)
+
+type eventOptions struct {
+	multiValue     bool
+	base64Body     *bool
+	stage          string
+	claims         map[string]interface{}
+	requestID      string
+	sourceIP       string
+	resource       string
+	pathParameters map[string]string
+	routeKey       string
+	connectionID   string
+}
+
+// EventOption Option of the event builders.
+type EventOption func(o *eventOptions)
+
+// WithMultiValue Use multi-value headers and query strings for REST API, ALB and WebSocket API events.
+// The default is true.
+func WithMultiValue(multiValue bool) EventOption {
+	return func(o *eventOptions) {
+		o.multiValue = multiValue
+	}
+}
+
+// WithBase64Body Force the body to be base64 encoded or not.
+// By default, the body is base64 encoded unless the Content-Type is text.
+func WithBase64Body(encode bool) EventOption {
+	return func(o *eventOptions) {
+		o.base64Body = &encode
+	}
+}
+
+// WithStage Set the stage name. The default is DefaultStage for REST API and WebSocket API, and '$default' for HTTP API.
+// For HTTP API, the path is prefixed with the stage name unless the stage is '$default'.
+func WithStage(stage string) EventOption {
+	return func(o *eventOptions) {
+		o.stage = stage
+	}
+}
+
+// WithAuthorizerClaims Set the claims resolved by the authorizer.
+func WithAuthorizerClaims(claims map[string]interface{}) EventOption {
+	return func(o *eventOptions) {
+		o.claims = claims
+	}
+}
+
+// WithRequestID Set the request id. By default, a random id is generated.
+func WithRequestID(id string) EventOption {
+	return func(o *eventOptions) {
+		o.requestID = id
+	}
+}
+
+// WithSourceIP Set the source IP. By default, the host part of http.Request.RemoteAddr is used.
+func WithSourceIP(ip string) EventOption {
+	return func(o *eventOptions) {
+		o.sourceIP = ip
+	}
+}
+
+// WithResource Set the resource path and path parameters of REST API event.
+// By default, the resource is DefaultResourcePath and the path is set to the 'proxy' parameter.
+func WithResource(resource string, pathParameters map[string]string) EventOption {
+	return func(o *eventOptions) {
+		o.resource = resource
+		o.pathParameters = pathParameters
+	}
+}
+
+// WithRouteKey Set the route key of HTTP API and WebSocket API events.
+func WithRouteKey(routeKey string) EventOption {
+	return func(o *eventOptions) {
+		o.routeKey = routeKey
+	}
+}
+
+// WithConnectionID Set the connection id of WebSocket API event. By default, a random id is generated.
+func WithConnectionID(id string) EventOption {
+	return func(o *eventOptions) {
+		o.connectionID = id
+	}
+}
+
+func newEventOptions(r *http.Request, opts []EventOption) *eventOptions {
+	o := &eventOptions{
+		multiValue: true,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.requestID == "" {
+		o.requestID = newID(16)
+	}
+	if o.sourceIP == "" {
+		o.sourceIP = sourceIP(r)
+	}
+	return o
+}
+
+// NewRESTAPIRequest Build API Gateway REST API event from http.Request.
+func NewRESTAPIRequest(r *http.Request, opts ...EventOption) (*events.APIGatewayProxyRequest, error) {
+	o := newEventOptions(r, opts)
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := o.stage
+	if stage == "" {
+		stage = DefaultStage
+	}
+
+	resource, pathParameters := o.resource, o.pathParameters
+	if resource == "" {
+		resource = DefaultResourcePath
+		pathParameters = map[string]string{"proxy": strings.TrimPrefix(r.URL.Path, "/")}
+	}
+
+	now := time.Now()
+	e := &events.APIGatewayProxyRequest{
+		Resource:       resource,
+		Path:           r.URL.Path,
+		HTTPMethod:     r.Method,
+		PathParameters: pathParameters,
+		RequestContext: events.APIGatewayProxyRequestContext{
+			Stage:        stage,
+			DomainName:   r.Host,
+			RequestID:    o.requestID,
+			Protocol:     r.Proto,
+			ResourcePath: resource,
+			Path:         "/" + stage + r.URL.Path,
+			HTTPMethod:   r.Method,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  o.sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+			RequestTime:      now.UTC().Format(requestTimeFormat),
+			RequestTimeEpoch: now.UnixMilli(),
+		},
+	}
+
+	if o.claims != nil {
+		e.RequestContext.Authorizer = map[string]interface{}{"claims": o.claims}
+	}
+
+	e.Headers, e.MultiValueHeaders = foldHeaders(r.Header, r.Host, o.multiValue)
+	e.QueryStringParameters, e.MultiValueQueryStringParameters = foldQuery(r, o.multiValue)
+	e.Body, e.IsBase64Encoded = encodeBody(r.Header, body, o.base64Body)
+
+	return e, nil
+}
+
+// NewHTTPAPIRequest Build API Gateway HTTP API (payload format version 2.0) event from http.Request.
+func NewHTTPAPIRequest(r *http.Request, opts ...EventOption) (*events.APIGatewayV2HTTPRequest, error) {
+	o := newEventOptions(r, opts)
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := o.stage
+	if stage == "" {
+		stage = "$default"
+	}
+
+	rawPath := r.URL.Path
+	if stage != "$default" {
+		rawPath = "/" + stage + rawPath
+	}
+
+	routeKey := o.routeKey
+	if routeKey == "" {
+		routeKey = "$default"
+	}
+
+	now := time.Now()
+	e := &events.APIGatewayV2HTTPRequest{
+		Version:        "2.0",
+		RouteKey:       routeKey,
+		RawPath:        rawPath,
+		RawQueryString: r.URL.RawQuery,
+		RequestContext: events.APIGatewayV2HTTPRequestContext{
+			RouteKey:   routeKey,
+			Stage:      stage,
+			RequestID:  o.requestID,
+			DomainName: r.Host,
+			Time:       now.UTC().Format(requestTimeFormat),
+			TimeEpoch:  now.UnixMilli(),
+			HTTP: events.APIGatewayV2HTTPRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      rawPath,
+				Protocol:  r.Proto,
+				SourceIP:  o.sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	if o.claims != nil {
+		claims := make(map[string]string, len(o.claims))
+		for k, v := range o.claims {
+			claims[k] = fmt.Sprintf("%v", v)
+		}
+		e.RequestContext.Authorizer = &events.APIGatewayV2HTTPRequestContextAuthorizerDescription{
+			JWT: &events.APIGatewayV2HTTPRequestContextAuthorizerJWTDescription{Claims: claims},
+		}
+	}
+
+	e.Headers, e.Cookies = joinHeaders(r)
+	e.QueryStringParameters = joinQuery(r)
+	e.Body, e.IsBase64Encoded = encodeBody(r.Header, body, o.base64Body)
+
+	return e, nil
+}
+
+// NewFunctionURLRequest Build Lambda Function URL event from http.Request.
+func NewFunctionURLRequest(r *http.Request, opts ...EventOption) (*events.LambdaFunctionURLRequest, error) {
+	o := newEventOptions(r, opts)
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Function URLs always have the domain '<url-id>.lambda-url.<region>.on.aws'.
+	domain := r.Host
+	if !strings.Contains(domain, ".lambda-url.") {
+		domain = "test.lambda-url.us-east-1.on.aws"
+	}
+
+	now := time.Now()
+	e := &events.LambdaFunctionURLRequest{
+		Version:        "2.0",
+		RawPath:        r.URL.Path,
+		RawQueryString: r.URL.RawQuery,
+		RequestContext: events.LambdaFunctionURLRequestContext{
+			RequestID:    o.requestID,
+			DomainName:   domain,
+			DomainPrefix: strings.SplitN(domain, ".", 2)[0],
+			Time:         now.UTC().Format(requestTimeFormat),
+			TimeEpoch:    now.UnixMilli(),
+			HTTP: events.LambdaFunctionURLRequestContextHTTPDescription{
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Protocol:  r.Proto,
+				SourceIP:  o.sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+		},
+	}
+
+	e.Headers, e.Cookies = joinHeaders(r)
+	e.QueryStringParameters = joinQuery(r)
+	e.Body, e.IsBase64Encoded = encodeBody(r.Header, body, o.base64Body)
+
+	return e, nil
+}
+
+// NewALBTargetGroupRequest Build Application Load Balancer target group event from http.Request.
+func NewALBTargetGroupRequest(r *http.Request, opts ...EventOption) (*events.ALBTargetGroupRequest, error) {
+	o := newEventOptions(r, opts)
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &events.ALBTargetGroupRequest{
+		HTTPMethod: r.Method,
+		Path:       r.URL.Path,
+		RequestContext: events.ALBTargetGroupRequestContext{
+			ELB: events.ELBContext{
+				TargetGroupArn: "arn:aws:elasticloadbalancing:us-east-1:123456789012:targetgroup/test/0123456789abcdef",
+			},
+		},
+	}
+
+	header := r.Header.Clone()
+	header.Set(types.HTTPHeaderXForwardedFor, o.sourceIP)
+
+	e.Headers, e.MultiValueHeaders = foldHeaders(header, r.Host, o.multiValue)
+	e.QueryStringParameters, e.MultiValueQueryStringParameters = foldQuery(r, o.multiValue)
+	e.Body, e.IsBase64Encoded = encodeBody(r.Header, body, o.base64Body)
+
+	return e, nil
+}
+
+// NewWebsocketRequest Build API Gateway WebSocket API event from http.Request.
+// The event type is derived from the route key, '$connect', '$disconnect' or others.
+func NewWebsocketRequest(r *http.Request, opts ...EventOption) (*events.APIGatewayWebsocketProxyRequest, error) {
+	o := newEventOptions(r, opts)
+
+	body, err := readBody(r)
+	if err != nil {
+		return nil, err
+	}
+
+	stage := o.stage
+	if stage == "" {
+		stage = DefaultStage
+	}
+
+	routeKey := o.routeKey
+	if routeKey == "" {
+		routeKey = "$default"
+	}
+
+	connectionID := o.connectionID
+	if connectionID == "" {
+		connectionID = newID(8)
+	}
+
+	now := time.Now()
+	e := &events.APIGatewayWebsocketProxyRequest{
+		RequestContext: events.APIGatewayWebsocketProxyRequestContext{
+			Stage:     stage,
+			RequestID: o.requestID,
+			Identity: events.APIGatewayRequestIdentity{
+				SourceIP:  o.sourceIP,
+				UserAgent: r.UserAgent(),
+			},
+			ConnectedAt:       now.UnixMilli(),
+			ConnectionID:      connectionID,
+			DomainName:        r.Host,
+			ExtendedRequestID: o.requestID,
+			MessageDirection:  "IN",
+			RequestTime:       now.UTC().Format(requestTimeFormat),
+			RequestTimeEpoch:  now.UnixMilli(),
+			RouteKey:          routeKey,
+		},
+	}
+
+	if o.claims != nil {
+		e.RequestContext.Authorizer = o.claims
+	}
+
+	switch routeKey {
+	case "$connect":
+		// Only $connect route has headers and query strings.
+		e.RequestContext.EventType = "CONNECT"
+		e.Headers, e.MultiValueHeaders = foldHeaders(r.Header, r.Host, o.multiValue)
+		e.QueryStringParameters, e.MultiValueQueryStringParameters = foldQuery(r, o.multiValue)
+	case "$disconnect":
+		e.RequestContext.EventType = "DISCONNECT"
+	default:
+		e.RequestContext.EventType = "MESSAGE"
+		e.RequestContext.MessageID = newID(8)
+		e.Body, e.IsBase64Encoded = encodeBody(r.Header, body, o.base64Body)
+	}
+
+	return e, nil
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, fmt.Errorf("awsevents: read body: %w", err)
+	}
+	// restore body for the caller
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return body, nil
+}
+
+// foldHeaders REST API and ALB pass only the last value of the header in the single value mode.
+func foldHeaders(h http.Header, host string, multiValue bool) (headers map[string]string, multiValueHeaders map[string][]string) {
+	if multiValue {
+		multiValueHeaders = make(map[string][]string, len(h)+1)
+		for k, v := range h {
+			multiValueHeaders[strings.ToLower(k)] = v
+		}
+		if host != "" {
+			multiValueHeaders[types.HTTPHeaderHost] = []string{host}
+		}
+		return
+	}
+	headers = make(map[string]string, len(h)+1)
+	for k, v := range h {
+		headers[strings.ToLower(k)] = v[len(v)-1]
+	}
+	if host != "" {
+		headers[types.HTTPHeaderHost] = host
+	}
+	return
+}
+
+// joinHeaders HTTP API and Function URLs join multiple header values with comma, and pass cookies separately.
+func joinHeaders(r *http.Request) (headers map[string]string, cookies []string) {
+	headers = make(map[string]string, len(r.Header)+1)
+	for k, v := range r.Header {
+		if strings.EqualFold(k, types.HTTPHeaderCookie) {
+			for _, c := range r.Cookies() {
+				cookies = append(cookies, c.String())
+			}
+			continue
+		}
+		headers[strings.ToLower(k)] = strings.Join(v, ",")
+	}
+	if r.Host != "" {
+		headers[types.HTTPHeaderHost] = r.Host
+	}
+	return
+}
+
+func foldQuery(r *http.Request, multiValue bool) (params map[string]string, multiValueParams map[string][]string) {
+	query := r.URL.Query()
+	if len(query) == 0 {
+		return
+	}
+	if multiValue {
+		return nil, query
+	}
+	params = make(map[string]string, len(query))
+	for k, v := range query {
+		params[k] = v[len(v)-1]
+	}
+	return
+}
+
+func joinQuery(r *http.Request) map[string]string {
+	query := r.URL.Query()
+	if len(query) == 0 {
+		return nil
+	}
+	params := make(map[string]string, len(query))
+	for k, v := range query {
+		params[k] = strings.Join(v, ",")
+	}
+	return params
+}
+
+func encodeBody(h http.Header, body []byte, base64Body *bool) (string, bool) {
+	if len(body) == 0 {
+		return "", false
+	}
+	encode := !utils.IsTextContent(h.Get(types.HTTPHeaderContentType))
+	if base64Body != nil {
+		encode = *base64Body
+	}
+	if encode {
+		return base64.StdEncoding.EncodeToString(body), true
+	}
+	return string(body), false
+}
+
+func sourceIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func newID(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
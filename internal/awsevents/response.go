@@ -0,0 +1,136 @@
+package awsevents
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// streamingPreludeDelimiter separates the JSON prelude from the body in the Function URL streaming response.
+var streamingPreludeDelimiter = make([]byte, 8)
+
+// DecodeResponse Decode the response of the adaptor into http.Response.
+// Supported types are the response structs of REST API, HTTP API, ALB, WebSocket API and Function URLs,
+// and io.Reader of the Function URL streaming response.
+func DecodeResponse(v interface{}) (*http.Response, error) {
+	switch res := v.(type) {
+	case *events.APIGatewayProxyResponse:
+		return DecodeRESTAPIResponse(res)
+	case *events.APIGatewayV2HTTPResponse:
+		return DecodeHTTPAPIResponse(res)
+	case *events.ALBTargetGroupResponse:
+		return DecodeALBTargetGroupResponse(res)
+	case *events.LambdaFunctionURLResponse:
+		return DecodeFunctionURLResponse(res)
+	case io.Reader:
+		return DecodeFunctionURLStreamingResponse(res)
+	default:
+		return nil, fmt.Errorf("awsevents: unsupported response type %T", v)
+	}
+}
+
+// DecodeRESTAPIResponse Decode API Gateway REST API and WebSocket API response into http.Response.
+func DecodeRESTAPIResponse(res *events.APIGatewayProxyResponse) (*http.Response, error) {
+	return newResponse(res.StatusCode, res.Headers, res.MultiValueHeaders, nil, res.Body, res.IsBase64Encoded)
+}
+
+// DecodeHTTPAPIResponse Decode API Gateway HTTP API response into http.Response.
+func DecodeHTTPAPIResponse(res *events.APIGatewayV2HTTPResponse) (*http.Response, error) {
+	return newResponse(res.StatusCode, res.Headers, res.MultiValueHeaders, res.Cookies, res.Body, res.IsBase64Encoded)
+}
+
+// DecodeALBTargetGroupResponse Decode Application Load Balancer response into http.Response.
+func DecodeALBTargetGroupResponse(res *events.ALBTargetGroupResponse) (*http.Response, error) {
+	return newResponse(res.StatusCode, res.Headers, res.MultiValueHeaders, nil, res.Body, res.IsBase64Encoded)
+}
+
+// DecodeFunctionURLResponse Decode Lambda Function URL buffered response into http.Response.
+func DecodeFunctionURLResponse(res *events.LambdaFunctionURLResponse) (*http.Response, error) {
+	return newResponse(res.StatusCode, res.Headers, nil, res.Cookies, res.Body, res.IsBase64Encoded)
+}
+
+// DecodeFunctionURLStreamingResponse Decode Lambda Function URL streaming response into http.Response.
+// The reader must start with the JSON prelude followed by 8 NUL bytes, as sent to the Runtime API.
+// The body of the returned response reads the rest of the stream.
+func DecodeFunctionURLStreamingResponse(r io.Reader) (*http.Response, error) {
+	br := bufio.NewReader(r)
+
+	var prelude []byte
+	for {
+		b, err := br.ReadBytes(0)
+		prelude = append(prelude, b...)
+		if err != nil {
+			return nil, fmt.Errorf("awsevents: read streaming prelude: %w", err)
+		}
+		if bytes.HasSuffix(prelude, streamingPreludeDelimiter) {
+			prelude = prelude[:len(prelude)-len(streamingPreludeDelimiter)]
+			break
+		}
+	}
+
+	var p struct {
+		StatusCode int               `json:"statusCode"`
+		Headers    map[string]string `json:"headers"`
+		Cookies    []string          `json:"cookies"`
+	}
+	if err := json.Unmarshal(prelude, &p); err != nil {
+		return nil, fmt.Errorf("awsevents: decode streaming prelude: %w", err)
+	}
+
+	res := newHTTPResponse(p.StatusCode, p.Headers, nil, p.Cookies)
+	res.ContentLength = -1
+	res.Body = io.NopCloser(br)
+	return res, nil
+}
+
+func newResponse(status int, headers map[string]string, multiValueHeaders map[string][]string, cookies []string, body string, isBase64Encoded bool) (*http.Response, error) {
+	b := []byte(body)
+	if isBase64Encoded {
+		var err error
+		if b, err = base64.StdEncoding.DecodeString(body); err != nil {
+			return nil, fmt.Errorf("awsevents: decode body: %w", err)
+		}
+	}
+
+	res := newHTTPResponse(status, headers, multiValueHeaders, cookies)
+	res.ContentLength = int64(len(b))
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	return res, nil
+}
+
+func newHTTPResponse(status int, headers map[string]string, multiValueHeaders map[string][]string, cookies []string) *http.Response {
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	header := http.Header{}
+	for k, v := range headers {
+		header.Set(k, v)
+	}
+	for k, vs := range multiValueHeaders {
+		// multi value headers take precedence over the single value headers, as API Gateway does.
+		header.Del(k)
+		for _, v := range vs {
+			header.Add(k, v)
+		}
+	}
+	for _, c := range cookies {
+		header.Add(types.HTTPHeaderSetCookie, c)
+	}
+
+	return &http.Response{
+		Status:     strconv.Itoa(status) + " " + http.StatusText(status),
+		StatusCode: status,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     header,
+	}
+}
@@ -6,8 +6,13 @@ type contextKey int
 
 const (
 	RawRequestValueContextKey contextKey = iota
+	RequestContextValueContextKey
 )
 
 func NewRawRequestValueContext(ctx context.Context, v interface{}) context.Context {
 	return context.WithValue(ctx, RawRequestValueContextKey, v)
 }
+
+func NewRequestContextValueContext(ctx context.Context, v interface{}) context.Context {
+	return context.WithValue(ctx, RequestContextValueContextKey, v)
+}
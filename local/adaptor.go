@@ -0,0 +1,142 @@
+/*
+Package local provides an adaptor for local development.
+
+The adaptor runs a plain http.Server, and is used as the fallback when no serverless environment is detected.
+Optionally, every request can be round-tripped through the AWS Lambda event converters,
+to catch Lambda-specific behaviour before deploying.
+WebSocket connections can also be accepted, and dispatched as the events of API Gateway WebSocket API.
+*/
+package local
+
+import (
+	"context"
+	"github.com/yacchi/lambda-http-adaptor/aws"
+	"github.com/yacchi/lambda-http-adaptor/registry"
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"net/http"
+	"os"
+	"slices"
+)
+
+const DefaultAddr = ":8080"
+
+// EmulationModeEnvKey Environment variable to select the emulation mode.
+// Available values are 'rest_api', 'http_api' and 'alb_target_group'.
+const EmulationModeEnvKey = "LOCAL_EMULATION"
+
+type EmulationMode int
+
+const (
+	NoEmulation EmulationMode = iota
+	RESTAPIEmulation
+	HTTPAPIEmulation
+	ALBTargetGroupEmulation
+)
+
+func ParseEmulationMode(s string) EmulationMode {
+	switch s {
+	case "rest_api":
+		return RESTAPIEmulation
+	case "http_api":
+		return HTTPAPIEmulation
+	case "alb_target_group":
+		return ALBTargetGroupEmulation
+	default:
+		return NoEmulation
+	}
+}
+
+type Option func(adaptor *Adaptor)
+
+// WithEmulation Round-trip every request through the AWS Lambda event converter of the mode.
+func WithEmulation(mode EmulationMode) Option {
+	return func(adaptor *Adaptor) {
+		adaptor.mode = mode
+	}
+}
+
+// WithStage Set the stage name of the emulated API Gateway.
+func WithStage(stage string) Option {
+	return func(adaptor *Adaptor) {
+		adaptor.stage = stage
+	}
+}
+
+// WithMultiValue Use multi-value headers and query strings for emulated REST API and ALB events.
+func WithMultiValue(multiValue bool) Option {
+	return func(adaptor *Adaptor) {
+		adaptor.multiValue = multiValue
+	}
+}
+
+type Adaptor struct {
+	s                        *http.Server
+	mode                     EmulationMode
+	stage                    string
+	multiValue               bool
+	websocketPath            string
+	routeSelectionExpression string
+	websocketRoutes          []string
+}
+
+func (a *Adaptor) ListenAndServe() error {
+	return a.s.ListenAndServe()
+}
+
+func (a *Adaptor) Shutdown(ctx context.Context) error {
+	return a.s.Shutdown(ctx)
+}
+
+func NewLocalAdaptor(addr string, h http.Handler, options []interface{}) types.Adaptor {
+	if addr == "" {
+		addr = DefaultAddr
+	}
+
+	a := &Adaptor{
+		mode:          ParseEmulationMode(os.Getenv(EmulationModeEnvKey)),
+		multiValue:    true,
+		websocketPath: os.Getenv(WebsocketPathEnvKey),
+	}
+
+	for _, opt := range options {
+		if localOpt, ok := opt.(Option); ok {
+			localOpt(a)
+		}
+	}
+
+	var gateway *websocketGateway
+	if a.websocketPath != "" {
+		gateway = newWebsocketGateway(a.stage, a.routeSelectionExpression, a.websocketRoutes)
+		options = append(slices.Clip(options), aws.WithAPIGatewayManagementAPI(gateway))
+	}
+
+	if a.mode != NoEmulation || gateway != nil {
+		lambdaHandler := aws.NewLambdaHandlerWithOption(h, options)
+		if a.mode != NoEmulation {
+			h = &emulator{
+				h:          lambdaHandler,
+				mode:       a.mode,
+				stage:      a.stage,
+				multiValue: a.multiValue,
+			}
+		}
+		if gateway != nil {
+			gateway.h = lambdaHandler
+			mux := http.NewServeMux()
+			mux.Handle(a.websocketPath, gateway)
+			mux.Handle("/", h)
+			h = mux
+		}
+	}
+
+	a.s = &http.Server{
+		Addr:    addr,
+		Handler: h,
+	}
+
+	return a
+}
+
+func init() {
+	registry.Registry.SetFallbackAdaptor("local", NewLocalAdaptor)
+}
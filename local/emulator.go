@@ -0,0 +1,92 @@
+package local
+
+import (
+	"fmt"
+	"github.com/yacchi/lambda-http-adaptor/aws"
+	"github.com/yacchi/lambda-http-adaptor/internal/awsevents"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"io"
+	"net/http"
+)
+
+const (
+	defaultRESTAPIStage = "local"
+)
+
+// emulator http.Handler that round-trips the request through the AWS Lambda event converters.
+type emulator struct {
+	h          *aws.LambdaHandler
+	mode       EmulationMode
+	stage      string
+	multiValue bool
+}
+
+func (e *emulator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	res, err := e.invoke(r)
+	if err != nil {
+		// Lambda function error results 502 Bad Gateway on API Gateway and ALB.
+		log.FromContext(r.Context()).Warn("local: invoke", "error", err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	writeResponse(w, res)
+}
+
+func (e *emulator) invoke(r *http.Request) (*http.Response, error) {
+	opts := []awsevents.EventOption{
+		awsevents.WithMultiValue(e.multiValue),
+	}
+
+	switch e.mode {
+	case RESTAPIEmulation:
+		stage := e.stage
+		if stage == "" {
+			stage = defaultRESTAPIStage
+		}
+		event, err := awsevents.NewRESTAPIRequest(r, append(opts, awsevents.WithStage(stage))...)
+		if err != nil {
+			return nil, err
+		}
+		res, err := e.h.InvokeRESTAPI(r.Context(), event)
+		if err != nil {
+			return nil, err
+		}
+		return awsevents.DecodeRESTAPIResponse(res)
+	case HTTPAPIEmulation:
+		if e.stage != "" {
+			opts = append(opts, awsevents.WithStage(e.stage))
+		}
+		event, err := awsevents.NewHTTPAPIRequest(r, opts...)
+		if err != nil {
+			return nil, err
+		}
+		res, err := e.h.InvokeHTTPAPI(r.Context(), event)
+		if err != nil {
+			return nil, err
+		}
+		return awsevents.DecodeHTTPAPIResponse(res)
+	case ALBTargetGroupEmulation:
+		event, err := awsevents.NewALBTargetGroupRequest(r, opts...)
+		if err != nil {
+			return nil, err
+		}
+		res, err := e.h.InvokeALBTargetGroup(r.Context(), event)
+		if err != nil {
+			return nil, err
+		}
+		return awsevents.DecodeALBTargetGroupResponse(res)
+	default:
+		return nil, fmt.Errorf("local: unsupported emulation mode %d", e.mode)
+	}
+}
+
+func writeResponse(w http.ResponseWriter, res *http.Response) {
+	defer res.Body.Close()
+	for k, vs := range res.Header {
+		for _, v := range vs {
+			w.Header().Add(k, v)
+		}
+	}
+	w.WriteHeader(res.StatusCode)
+	_, _ = io.Copy(w, res.Body)
+}
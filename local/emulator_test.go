@@ -0,0 +1,86 @@
+package local
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEmulator(t *testing.T) {
+	binary := []byte{0x89, 0x50, 0x4e, 0x47, 0x00, 0xff}
+
+	cases := []struct {
+		name   string
+		mode   EmulationMode
+		path   string
+		accept []string
+	}{
+		{name: "rest_api", mode: RESTAPIEmulation, path: "/upload", accept: []string{"a", "b"}},
+		{name: "http_api", mode: HTTPAPIEmulation, path: "/dev/upload", accept: []string{"a,b"}},
+		{name: "alb_target_group", mode: ALBTargetGroupEmulation, path: "/upload", accept: []string{"a", "b"}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			handler := http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+				assert.Equal(t, c.path, request.URL.Path)
+				assert.Equal(t, c.accept, request.Header.Values("Accept"))
+
+				body, err := io.ReadAll(request.Body)
+				assert.NoError(t, err)
+				assert.Equal(t, binary, body)
+
+				writer.Header().Set("Content-Type", "application/octet-stream")
+				writer.Header().Set("X-Mode", c.name)
+				writer.WriteHeader(http.StatusCreated)
+				writer.Write(body)
+			})
+
+			a := NewLocalAdaptor("", handler, []interface{}{
+				WithEmulation(c.mode),
+				WithStage("dev"),
+			}).(*Adaptor)
+
+			s := httptest.NewServer(a.s.Handler)
+			defer s.Close()
+
+			req, _ := http.NewRequest(http.MethodPost, s.URL+"/upload", bytes.NewReader(binary))
+			req.Header.Set("Content-Type", "application/octet-stream")
+			req.Header.Add("Accept", "a")
+			req.Header.Add("Accept", "b")
+
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			defer res.Body.Close()
+
+			body, err := io.ReadAll(res.Body)
+			assert.NoError(t, err)
+			assert.Equal(t, http.StatusCreated, res.StatusCode)
+			assert.Equal(t, c.name, res.Header.Get("X-Mode"))
+			assert.Equal(t, binary, body)
+		})
+	}
+}
+
+func TestEmulator_StripBasePath(t *testing.T) {
+	handler := aws.StripBasePath(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.Write([]byte(request.URL.Path))
+	}))
+
+	for _, mode := range []EmulationMode{RESTAPIEmulation, HTTPAPIEmulation} {
+		a := NewLocalAdaptor("", handler, []interface{}{WithEmulation(mode), WithStage("dev")}).(*Adaptor)
+		s := httptest.NewServer(a.s.Handler)
+
+		res, err := http.Get(s.URL + "/echo")
+		assert.NoError(t, err)
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		assert.Equal(t, "/echo", string(body))
+
+		s.Close()
+	}
+}
@@ -0,0 +1,301 @@
+package local
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"github.com/aws/aws-lambda-go/events"
+	"github.com/gorilla/websocket"
+	"github.com/yacchi/lambda-http-adaptor/aws"
+	"github.com/yacchi/lambda-http-adaptor/internal/awsevents"
+	"github.com/yacchi/lambda-http-adaptor/log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultWebsocketPath Default path of the emulated API Gateway WebSocket API endpoint.
+const DefaultWebsocketPath = "/websocket"
+
+// WebsocketPathEnvKey Environment variable to enable the emulated API Gateway WebSocket API on the path.
+const WebsocketPathEnvKey = "LOCAL_WEBSOCKET_PATH"
+
+// WithWebsocketGateway Accept WebSocket connections on the path, and invoke the handler as API Gateway WebSocket API.
+func WithWebsocketGateway(path string) Option {
+	return func(adaptor *Adaptor) {
+		adaptor.websocketPath = path
+	}
+}
+
+// WithRouteSelectionExpression Set the route selection expression of the emulated WebSocket API.
+// The default is aws.DefaultRouteSelectionExpression.
+func WithRouteSelectionExpression(expression string) Option {
+	return func(adaptor *Adaptor) {
+		adaptor.routeSelectionExpression = expression
+	}
+}
+
+// WithWebsocketRoutes Set the route keys of the emulated WebSocket API.
+// Messages selecting other routes are sent to $default route.
+// Without it, any route key selected by the expression is used.
+func WithWebsocketRoutes(routes ...string) Option {
+	return func(adaptor *Adaptor) {
+		adaptor.websocketRoutes = routes
+	}
+}
+
+// websocketGateway Emulation of API Gateway WebSocket API.
+// It invokes the handler for $connect, $disconnect and the routes of the messages,
+// and implements aws.APIGatewayManagementAPI by writing to the connections directly.
+type websocketGateway struct {
+	h          *aws.LambdaHandler
+	stage      string
+	expression string
+	routes     []string
+	upgrader   websocket.Upgrader
+
+	mu    sync.Mutex
+	conns map[string]*websocketConn
+}
+
+var _ aws.APIGatewayManagementAPI = (*websocketGateway)(nil)
+
+type websocketConn struct {
+	conn         *websocket.Conn
+	connectedAt  time.Time
+	sourceIP     string
+	userAgent    string
+	mu           sync.Mutex
+	lastActiveAt time.Time
+}
+
+func (c *websocketConn) write(messageType int, data []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.conn.WriteMessage(messageType, data)
+}
+
+func (c *websocketConn) touch() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lastActiveAt = time.Now()
+}
+
+func newWebsocketGateway(stage, expression string, routes []string) *websocketGateway {
+	if stage == "" {
+		stage = defaultRESTAPIStage
+	}
+	if expression == "" {
+		expression = aws.DefaultRouteSelectionExpression
+	}
+	return &websocketGateway{
+		stage:      stage,
+		expression: expression,
+		routes:     routes,
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		conns: map[string]*websocketConn{},
+	}
+}
+
+func newConnectionID() string {
+	b := make([]byte, 10)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+func (g *websocketGateway) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	connectionID := newConnectionID()
+	logger := log.FromContext(r.Context()).With("connectionId", connectionID)
+
+	opts := []awsevents.EventOption{
+		awsevents.WithStage(g.stage),
+		awsevents.WithConnectionID(connectionID),
+	}
+
+	event, err := awsevents.NewWebsocketRequest(r, append(opts, awsevents.WithRouteKey("$connect"))...)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	res, err := g.h.InvokeWebsocketAPI(r.Context(), event)
+	if err != nil {
+		logger.Warn("local: invoke $connect", "error", err)
+		http.Error(w, http.StatusText(http.StatusBadGateway), http.StatusBadGateway)
+		return
+	}
+	// The connection is rejected unless $connect route responds with 2xx.
+	if res.StatusCode != 0 && (res.StatusCode < 200 || http.StatusMultipleChoices <= res.StatusCode) {
+		http.Error(w, http.StatusText(res.StatusCode), res.StatusCode)
+		return
+	}
+
+	// The connection accepted by $connect is disconnected even if the upgrade fails,
+	// so that the handler can release it, such as the record of ConnectionRegistry.
+	defer func() {
+		event, err := awsevents.NewWebsocketRequest(newMessageRequest(r, nil), append(opts, awsevents.WithRouteKey("$disconnect"))...)
+		if err == nil {
+			_, err = g.h.InvokeWebsocketAPI(context.WithoutCancel(r.Context()), event)
+		}
+		if err != nil {
+			logger.Warn("local: invoke $disconnect", "error", err)
+		}
+	}()
+
+	header := http.Header{}
+	if protocol := responseHeader(res, "Sec-WebSocket-Protocol"); protocol != "" {
+		header.Set("Sec-WebSocket-Protocol", protocol)
+	}
+	conn, err := g.upgrader.Upgrade(w, r, header)
+	if err != nil {
+		logger.Warn("local: upgrade websocket", "error", err)
+		return
+	}
+
+	now := time.Now()
+	c := &websocketConn{
+		conn:         conn,
+		connectedAt:  now,
+		lastActiveAt: now,
+		sourceIP:     event.RequestContext.Identity.SourceIP,
+		userAgent:    r.UserAgent(),
+	}
+	g.mu.Lock()
+	g.conns[connectionID] = c
+	g.mu.Unlock()
+
+	defer func() {
+		g.mu.Lock()
+		delete(g.conns, connectionID)
+		g.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.touch()
+		if err := g.invokeRoute(r, c, opts, messageType, data); err != nil {
+			logger.Warn("local: invoke route", "error", err)
+		}
+	}
+}
+
+// invokeRoute Invoke the handler with the message, and send the response body back as two-way route does.
+func (g *websocketGateway) invokeRoute(r *http.Request, c *websocketConn, opts []awsevents.EventOption, messageType int, data []byte) error {
+	binary := messageType == websocket.BinaryMessage
+	event, err := awsevents.NewWebsocketRequest(newMessageRequest(r, data),
+		append(opts, awsevents.WithRouteKey("$default"), awsevents.WithBase64Body(binary))...)
+	if err != nil {
+		return err
+	}
+	if !binary {
+		event.RequestContext.RouteKey = g.selectRoute(event)
+	}
+
+	res, err := g.h.InvokeWebsocketAPI(r.Context(), event)
+	if err != nil {
+		return err
+	}
+	if res.Body == "" {
+		return nil
+	}
+	if res.IsBase64Encoded {
+		body, err := base64.StdEncoding.DecodeString(res.Body)
+		if err != nil {
+			return fmt.Errorf("local: decode base64 body: %w", err)
+		}
+		return c.write(websocket.BinaryMessage, body)
+	}
+	return c.write(websocket.TextMessage, []byte(res.Body))
+}
+
+// selectRoute Evaluate the route selection expression, falling back to $default.
+func (g *websocketGateway) selectRoute(event *events.APIGatewayWebsocketProxyRequest) string {
+	route, err := aws.RouteSelector(event, g.expression)
+	if err != nil || route == "" {
+		return "$default"
+	}
+	if g.routes != nil && !slices.Contains(g.routes, route) {
+		return "$default"
+	}
+	return route
+}
+
+// newMessageRequest Request to build the events after $connect, which have no headers and query strings.
+func newMessageRequest(r *http.Request, body []byte) *http.Request {
+	req, _ := http.NewRequestWithContext(r.Context(), http.MethodPost, "/", strings.NewReader(string(body)))
+	req.Host = r.Host
+	req.RemoteAddr = r.RemoteAddr
+	req.Header.Set("User-Agent", r.UserAgent())
+	return req
+}
+
+func responseHeader(res *events.APIGatewayProxyResponse, key string) string {
+	if v := http.Header(res.MultiValueHeaders).Get(key); v != "" {
+		return v
+	}
+	for k, v := range res.Headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+func (g *websocketGateway) conn(connectionID string) (*websocketConn, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	c, ok := g.conns[connectionID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", aws.ErrConnectionGone, connectionID)
+	}
+	return c, nil
+}
+
+func (g *websocketGateway) PostToConnection(ctx context.Context, connectionID string, data []byte) error {
+	c, err := g.conn(connectionID)
+	if err != nil {
+		return err
+	}
+	messageType := websocket.TextMessage
+	if !utf8.Valid(data) {
+		messageType = websocket.BinaryMessage
+	}
+	return c.write(messageType, data)
+}
+
+func (g *websocketGateway) DeleteConnection(ctx context.Context, connectionID string) error {
+	c, err := g.conn(connectionID)
+	if err != nil {
+		return err
+	}
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	if err := c.write(websocket.CloseMessage, msg); err != nil {
+		return err
+	}
+	// The read loop ends with the close, and invokes $disconnect.
+	return c.conn.Close()
+}
+
+func (g *websocketGateway) GetConnection(ctx context.Context, connectionID string) (*aws.ConnectionInfo, error) {
+	c, err := g.conn(connectionID)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &aws.ConnectionInfo{
+		ConnectedAt:  c.connectedAt,
+		LastActiveAt: c.lastActiveAt,
+		SourceIP:     c.sourceIP,
+		UserAgent:    c.userAgent,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package local
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/yacchi/lambda-http-adaptor/aws"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebsocketGateway(t *testing.T) {
+	disconnected := make(chan string, 1)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/local/websocket/$connect", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("token") != "secret" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/local/websocket/echo", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Write(body)
+	})
+	mux.HandleFunc("/local/websocket/$default", func(w http.ResponseWriter, r *http.Request) {
+		conn, ok := aws.GetWebsocketConnection(r.Context())
+		if !assert.True(t, ok) {
+			return
+		}
+		info, err := conn.Info(r.Context())
+		assert.NoError(t, err)
+		assert.False(t, info.ConnectedAt.IsZero())
+		assert.NoError(t, conn.Close(r.Context()))
+	})
+	mux.HandleFunc("/local/websocket/$disconnect", func(w http.ResponseWriter, r *http.Request) {
+		rc, _ := aws.GetWebsocketRequestContext(r.Context())
+		disconnected <- rc.ConnectionID
+	})
+
+	a := NewLocalAdaptor("", mux, []interface{}{WithWebsocketGateway("/ws"), WithWebsocketRoutes("echo")}).(*Adaptor)
+	server := httptest.NewServer(a.s.Handler)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws"
+
+	_, res, err := websocket.DefaultDialer.Dial(url, nil)
+	assert.Error(t, err)
+	if assert.NotNil(t, res) {
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?token=secret", nil)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"echo","message":"hello"}`)))
+	_, msg, err := conn.ReadMessage()
+	assert.NoError(t, err)
+	assert.Equal(t, `{"action":"echo","message":"hello"}`, string(msg))
+
+	// unknown routes are sent to $default, which closes the connection.
+	assert.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte(`{"action":"unknown"}`)))
+	_, _, err = conn.ReadMessage()
+	assert.True(t, websocket.IsCloseError(err, websocket.CloseNormalClosure))
+
+	select {
+	case id := <-disconnected:
+		assert.NotEmpty(t, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("$disconnect was not invoked")
+	}
+
+	// the connection accepted by $connect is disconnected when the upgrade fails.
+	plain, err := http.Get(server.URL + "/ws?token=secret")
+	if assert.NoError(t, err) {
+		_ = plain.Body.Close()
+		assert.Equal(t, http.StatusBadRequest, plain.StatusCode)
+	}
+	select {
+	case id := <-disconnected:
+		assert.NotEmpty(t, id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("$disconnect was not invoked after the upgrade failure")
+	}
+}
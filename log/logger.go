@@ -1,9 +1,152 @@
 package log
 
-import "log"
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
 
+const (
+	// LogFormatEnvKey Log format configured by the advanced logging controls of Lambda, 'Text' or 'JSON'.
+	LogFormatEnvKey = "AWS_LAMBDA_LOG_FORMAT"
+	// LogLevelEnvKey Application log level configured by the advanced logging controls of Lambda.
+	LogLevelEnvKey = "AWS_LAMBDA_LOG_LEVEL"
+)
+
+const (
+	// LevelTrace TRACE level of Lambda, lower than slog.LevelDebug.
+	LevelTrace = slog.LevelDebug - 4
+	// LevelFatal FATAL level of Lambda, higher than slog.LevelError.
+	LevelFatal = slog.LevelError + 4
+)
+
+// Writer Deprecated: Use Default or FromContext.
 type Writer func(...interface{})
 
-var Info Writer = log.Println
+// Info Deprecated: Use Default or FromContext.
+var Info Writer = func(v ...interface{}) {
+	Default().Info(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+// Warning Deprecated: Use Default or FromContext.
+var Warning Writer = func(v ...interface{}) {
+	Default().Warn(strings.TrimSuffix(fmt.Sprintln(v...), "\n"))
+}
+
+var defaultLogger atomic.Pointer[slog.Logger]
+
+func init() {
+	defaultLogger.Store(slog.New(NewHandler(os.Stderr)))
+}
+
+// Default Logger used by the adaptor for its own diagnostics.
+// The default writes to stderr with the format and the level configured by the environment variables. See NewHandler.
+func Default() *slog.Logger {
+	return defaultLogger.Load()
+}
+
+// SetDefault Replace the logger used by the adaptor.
+func SetDefault(l *slog.Logger) {
+	defaultLogger.Store(l)
+}
+
+// NewHandler Create slog.Handler following the advanced logging controls of Lambda.
+// If AWS_LAMBDA_LOG_FORMAT is 'JSON', the handler is NewLambdaJSONHandler, otherwise slog.TextHandler.
+// The level is taken from AWS_LAMBDA_LOG_LEVEL, and defaults to INFO.
+func NewHandler(w io.Writer) slog.Handler {
+	opts := &slog.HandlerOptions{}
+	if level, ok := ParseLevel(os.Getenv(LogLevelEnvKey)); ok {
+		opts.Level = level
+	}
+	if strings.EqualFold(os.Getenv(LogFormatEnvKey), "JSON") {
+		return NewLambdaJSONHandler(w, opts)
+	}
+	return slog.NewTextHandler(w, opts)
+}
+
+// NewLambdaJSONHandler Create slog.JSONHandler emitting the records in the JSON log format of Lambda,
+// {"timestamp": "...", "level": "INFO", "message": "...", ...}, so that the log level filtering of Lambda works.
+func NewLambdaJSONHandler(w io.Writer, opts *slog.HandlerOptions) slog.Handler {
+	var o slog.HandlerOptions
+	if opts != nil {
+		o = *opts
+	}
+	replace := o.ReplaceAttr
+	o.ReplaceAttr = func(groups []string, a slog.Attr) slog.Attr {
+		if len(groups) == 0 {
+			switch a.Key {
+			case slog.TimeKey:
+				a.Key = "timestamp"
+			case slog.MessageKey:
+				a.Key = "message"
+			case slog.LevelKey:
+				if level, ok := a.Value.Any().(slog.Level); ok {
+					a.Value = slog.StringValue(LevelName(level))
+				}
+			}
+		}
+		if replace != nil {
+			return replace(groups, a)
+		}
+		return a
+	}
+	return slog.NewJSONHandler(w, &o)
+}
+
+// ParseLevel Parse the level name of Lambda, TRACE, DEBUG, INFO, WARN, ERROR and FATAL.
+func ParseLevel(s string) (slog.Level, bool) {
+	switch strings.ToUpper(s) {
+	case "TRACE":
+		return LevelTrace, true
+	case "DEBUG":
+		return slog.LevelDebug, true
+	case "INFO":
+		return slog.LevelInfo, true
+	case "WARN":
+		return slog.LevelWarn, true
+	case "ERROR":
+		return slog.LevelError, true
+	case "FATAL":
+		return LevelFatal, true
+	}
+	return 0, false
+}
+
+// LevelName Level name of Lambda for the level.
+func LevelName(level slog.Level) string {
+	switch {
+	case level < slog.LevelDebug:
+		return "TRACE"
+	case level < slog.LevelInfo:
+		return "DEBUG"
+	case level < slog.LevelWarn:
+		return "INFO"
+	case level < slog.LevelError:
+		return "WARN"
+	case level < LevelFatal:
+		return "ERROR"
+	default:
+		return "FATAL"
+	}
+}
+
+type loggerContextKey struct{}
+
+// NewContext Return the context holding the logger.
+func NewContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey{}, l)
+}
 
-var Warning Writer = log.Println
+// FromContext Return the logger held by the context, or Default.
+// For requests served by the adaptors, the logger is enriched with the attributes of the request,
+// such as the request ID and the route.
+func FromContext(ctx context.Context) *slog.Logger {
+	if l, ok := ctx.Value(loggerContextKey{}).(*slog.Logger); ok {
+		return l
+	}
+	return Default()
+}
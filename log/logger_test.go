@@ -0,0 +1,57 @@
+package log
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"log/slog"
+	"testing"
+)
+
+func TestNewLambdaJSONHandler(t *testing.T) {
+	var buf bytes.Buffer
+	l := slog.New(NewLambdaJSONHandler(&buf, &slog.HandlerOptions{Level: LevelTrace}))
+	l.Log(context.Background(), LevelTrace, "trace", "requestId", "request-id")
+
+	var record map[string]any
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "TRACE", record["level"])
+	assert.Equal(t, "trace", record["message"])
+	assert.Equal(t, "request-id", record["requestId"])
+	assert.NotEmpty(t, record["timestamp"])
+	assert.NotContains(t, record, "msg")
+	assert.NotContains(t, record, "time")
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, name := range []string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"} {
+		level, ok := ParseLevel(name)
+		assert.True(t, ok)
+		assert.Equal(t, name, LevelName(level))
+	}
+	level, ok := ParseLevel("warn")
+	assert.True(t, ok)
+	assert.Equal(t, slog.LevelWarn, level)
+	_, ok = ParseLevel("verbose")
+	assert.False(t, ok)
+}
+
+func TestFromContext(t *testing.T) {
+	assert.Same(t, Default(), FromContext(context.Background()))
+
+	l := Default().With("key", "value")
+	assert.Same(t, l, FromContext(NewContext(context.Background(), l)))
+}
+
+func TestNewHandler(t *testing.T) {
+	t.Setenv(LogFormatEnvKey, "JSON")
+	t.Setenv(LogLevelEnvKey, "ERROR")
+
+	var buf bytes.Buffer
+	l := slog.New(NewHandler(&buf))
+	l.Warn("filtered")
+	assert.Empty(t, buf.String())
+	l.Error("emitted")
+	assert.Contains(t, buf.String(), `"level":"ERROR"`)
+}
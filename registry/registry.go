@@ -13,6 +13,7 @@ type provider struct {
 
 type registry struct {
 	providers []*provider
+	fallback  *provider
 }
 
 func (r *registry) AddAdaptor(name string, detector types.EnvironmentDetector, adaptor types.AdaptorInitializer) {
@@ -23,12 +24,23 @@ func (r *registry) AddAdaptor(name string, detector types.EnvironmentDetector, a
 	})
 }
 
+// SetFallbackAdaptor Set the adaptor used when no environment is detected.
+func (r *registry) SetFallbackAdaptor(name string, adaptor types.AdaptorInitializer) {
+	r.fallback = &provider{
+		Name: name,
+		Init: adaptor,
+	}
+}
+
 func (r *registry) GetAdaptor(addr string, h http.Handler, opts ...interface{}) types.Adaptor {
 	for _, d := range r.providers {
 		if d.EnvDetector() {
 			return d.Init(addr, h, opts)
 		}
 	}
+	if r.fallback != nil {
+		return r.fallback.Init(addr, h, opts)
+	}
 	return nil
 }
 
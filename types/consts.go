@@ -9,6 +9,7 @@ const (
 	HTTPHeaderHost            = "host"
 	HTTPHeaderContentLength   = "Content-Length"
 	HTTPHeaderCookie          = "cookie"
+	HTTPHeaderSetCookie       = "Set-Cookie"
 	HTTPHeaderXRayTraceIDKey  = "x-amzn-trace-id"
 )
 
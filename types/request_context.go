@@ -0,0 +1,23 @@
+package types
+
+import "time"
+
+// RequestContext Provider-neutral view of the request context attached by each integration.
+type RequestContext interface {
+	// RequestID returns the request identifier issued by the integration.
+	RequestID() string
+	// Stage returns the deployment stage name. Empty if the integration has no stage concept.
+	Stage() string
+	// SourceIP returns the IP address of the client.
+	SourceIP() string
+	// UserAgent returns the User-Agent of the client.
+	UserAgent() string
+	// DomainName returns the domain name used by the client.
+	DomainName() string
+	// AuthorizerClaims returns the claims or context values resolved by the authorizer. Nil if not authorized.
+	AuthorizerClaims() map[string]interface{}
+	// IntegrationType returns the name of the integration that received the request.
+	IntegrationType() string
+	// RequestTime returns the time the integration received the request. Zero if unknown.
+	RequestTime() time.Time
+}
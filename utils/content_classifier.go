@@ -0,0 +1,175 @@
+package utils
+
+import (
+	"github.com/yacchi/lambda-http-adaptor/types"
+	"mime"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultContentClassifier Used by the package level functions, and by handlers without their own classifier.
+var DefaultContentClassifier = NewContentClassifier()
+
+// ContentClassifier Classify the content as binary or text, to decide whether the body is base64 encoded.
+// It is safe for concurrent use.
+type ContentClassifier struct {
+	mu                    sync.RWMutex
+	binaryContentEncoding map[string]struct{}
+	textMIMEType          map[string]struct{}
+	textMIMERegexp        []*regexp.Regexp
+	binaryMediaTypes      []string
+	// cache Results of IsTextContent by media type, cleared when the rules are changed.
+	cache *sync.Map
+}
+
+// NewContentClassifier creates a new ContentClassifier with the default rules.
+func NewContentClassifier() *ContentClassifier {
+	return &ContentClassifier{
+		binaryContentEncoding: map[string]struct{}{
+			"gzip":    {},
+			"x-gzip":  {},
+			"deflate": {},
+			"br":      {},
+		},
+		textMIMEType: map[string]struct{}{
+			"image/svg+xml":          {},
+			"application/json":       {},
+			"application/javascript": {},
+			"application/xml":        {},
+		},
+		textMIMERegexp: []*regexp.Regexp{
+			regexp.MustCompile("^text/"),
+		},
+		cache: &sync.Map{},
+	}
+}
+
+// Clone creates a copy of the classifier, so that the rules can be changed without affecting the original.
+func (c *ContentClassifier) Clone() *ContentClassifier {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	clone := &ContentClassifier{
+		binaryContentEncoding: make(map[string]struct{}, len(c.binaryContentEncoding)),
+		textMIMEType:          make(map[string]struct{}, len(c.textMIMEType)),
+		textMIMERegexp:        append([]*regexp.Regexp{}, c.textMIMERegexp...),
+		binaryMediaTypes:      append([]string{}, c.binaryMediaTypes...),
+		cache:                 &sync.Map{},
+	}
+	for k := range c.binaryContentEncoding {
+		clone.binaryContentEncoding[k] = struct{}{}
+	}
+	for k := range c.textMIMEType {
+		clone.textMIMEType[k] = struct{}{}
+	}
+	return clone
+}
+
+func (c *ContentClassifier) update(f func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f()
+	c.cache = &sync.Map{}
+}
+
+// RegisterBinaryContentEncoding Content with the encoding is always binary.
+func (c *ContentClassifier) RegisterBinaryContentEncoding(t string) {
+	c.update(func() {
+		c.binaryContentEncoding[t] = struct{}{}
+	})
+}
+
+// RegisterTextMIMEType Content of the MIME type is text.
+func (c *ContentClassifier) RegisterTextMIMEType(t string) {
+	c.update(func() {
+		c.textMIMEType[t] = struct{}{}
+	})
+}
+
+// RegisterTextMIMERegexp Content of the MIME type matched by the regexp is text.
+func (c *ContentClassifier) RegisterTextMIMERegexp(r *regexp.Regexp) {
+	c.update(func() {
+		c.textMIMERegexp = append(c.textMIMERegexp, r)
+	})
+}
+
+// AddBinaryMediaTypes Content of the media types is binary, even if it is text, as binaryMediaTypes of API Gateway.
+// Wildcards such as 'image/*' and '*/*' are supported.
+func (c *ContentClassifier) AddBinaryMediaTypes(mediaTypes ...string) {
+	c.update(func() {
+		c.binaryMediaTypes = append(c.binaryMediaTypes, mediaTypes...)
+	})
+}
+
+// IsBinaryContent reports whether the content of the headers is binary.
+func (c *ContentClassifier) IsBinaryContent(h http.Header) bool {
+	c.mu.RLock()
+	for _, t := range h.Values(types.HTTPHeaderContentEncoding) {
+		if _, ok := c.binaryContentEncoding[t]; ok {
+			c.mu.RUnlock()
+			return true
+		}
+	}
+	c.mu.RUnlock()
+
+	return !c.IsTextContent(h.Get(types.HTTPHeaderContentType))
+}
+
+// IsTextContent reports whether the content type is text.
+func (c *ContentClassifier) IsTextContent(contentType string) bool {
+	m, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if v, ok := c.cache.Load(m); ok {
+		return v.(bool)
+	}
+	isText := c.isTextMediaType(m)
+	c.cache.Store(m, isText)
+	return isText
+}
+
+func (c *ContentClassifier) isTextMediaType(m string) bool {
+	for _, pattern := range c.binaryMediaTypes {
+		if MatchMediaType(pattern, m) {
+			return false
+		}
+	}
+
+	if _, ok := c.textMIMEType[m]; ok {
+		return true
+	}
+
+	for _, re := range c.textMIMERegexp {
+		if re.MatchString(m) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MatchMediaType Match the media type with the pattern, such as 'application/json', 'image/*', '*/*' and '*/*+json'.
+func MatchMediaType(pattern, mediaType string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == mediaType || pattern == "*/*" {
+		return true
+	}
+	pt, ps, _ := strings.Cut(pattern, "/")
+	mt, ms, _ := strings.Cut(mediaType, "/")
+	if pt != "*" && pt != mt {
+		return false
+	}
+	if ps == "*" {
+		return true
+	}
+	if strings.HasPrefix(ps, "*+") {
+		return strings.HasSuffix(ms, ps[1:])
+	}
+	return ps == ms
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+func TestContentClassifier_IsTextContent(t *testing.T) {
+	c := NewContentClassifier()
+	c.AddBinaryMediaTypes("image/*", "application/vnd.custom+json")
+
+	cases := []struct {
+		contentType string
+		text        bool
+	}{
+		{"text/html; charset=utf-8", true},
+		{"application/json", true},
+		{"image/svg+xml", false},
+		{"image/png", false},
+		{"application/vnd.custom+json", false},
+		{"application/octet-stream", false},
+		{"", false},
+	}
+	for _, tc := range cases {
+		assert.Equal(t, tc.text, c.IsTextContent(tc.contentType), tc.contentType)
+	}
+
+	all := NewContentClassifier()
+	all.AddBinaryMediaTypes("*/*")
+	assert.False(t, all.IsTextContent("text/plain"))
+
+	// the default classifier is not affected
+	assert.True(t, IsTextContent("image/svg+xml"))
+}
+
+func TestContentClassifier_IsBinaryContent(t *testing.T) {
+	c := NewContentClassifier()
+	h := http.Header{}
+	h.Set("Content-Type", "application/json")
+	assert.False(t, c.IsBinaryContent(h))
+
+	h.Set("Content-Encoding", "zstd")
+	assert.False(t, c.IsBinaryContent(h))
+	c.RegisterBinaryContentEncoding("zstd")
+	assert.True(t, c.IsBinaryContent(h))
+}
+
+func TestContentClassifier_Clone(t *testing.T) {
+	c := NewContentClassifier()
+	c.AddBinaryMediaTypes("image/*")
+	clone := c.Clone()
+	clone.RegisterBinaryContentEncoding("zstd")
+	clone.AddBinaryMediaTypes("application/json")
+
+	h := http.Header{}
+	h.Set("Content-Type", "text/plain")
+	h.Set("Content-Encoding", "zstd")
+	assert.False(t, c.IsBinaryContent(h))
+	assert.True(t, clone.IsBinaryContent(h))
+	assert.True(t, c.IsTextContent("application/json"))
+	assert.False(t, clone.IsTextContent("application/json"))
+	assert.False(t, clone.IsTextContent("image/png"))
+}
+
+func TestContentClassifier_Concurrent(t *testing.T) {
+	c := NewContentClassifier()
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				c.IsTextContent("application/x-ndjson")
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			c.RegisterTextMIMERegexp(regexp.MustCompile(`^application/x-ndjson$`))
+			c.RegisterTextMIMEType("application/yaml")
+		}()
+	}
+	wg.Wait()
+	assert.True(t, c.IsTextContent("application/x-ndjson"))
+}
+
+func TestMatchMediaType(t *testing.T) {
+	assert.True(t, MatchMediaType("*/*", "application/json"))
+	assert.True(t, MatchMediaType("image/*", "image/png"))
+	assert.False(t, MatchMediaType("image/*", "text/plain"))
+	assert.True(t, MatchMediaType("*/*+json", "application/problem+json"))
+	assert.True(t, MatchMediaType("Application/JSON", "application/json"))
+	assert.False(t, MatchMediaType("application/json", "application/xml"))
+}
@@ -3,9 +3,15 @@ package utils
 import (
 	"context"
 	"github.com/yacchi/lambda-http-adaptor/internal"
+	"github.com/yacchi/lambda-http-adaptor/types"
 )
 
 func RawRequestValue(ctx context.Context) (interface{}, bool) {
 	v := ctx.Value(internal.RawRequestValueContextKey)
 	return v, v != nil
 }
+
+func RequestContextValue(ctx context.Context) (types.RequestContext, bool) {
+	v, ok := ctx.Value(internal.RequestContextValueContextKey).(types.RequestContext)
+	return v, ok
+}
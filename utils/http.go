@@ -1,74 +1,35 @@
 package utils
 
 import (
-	"github.com/yacchi/lambda-http-adaptor/types"
-	"mime"
 	"net/http"
 	"regexp"
 	"sort"
 	"strings"
 )
 
-var binaryContentEncoding = map[string]struct{}{
-	"gzip":    {},
-	"x-gzip":  {},
-	"deflate": {},
-	"br":      {},
-}
-
+// RegisterBinaryContentEncoding Register the content encoding to DefaultContentClassifier.
 func RegisterBinaryContentEncoding(t string) {
-	binaryContentEncoding[t] = struct{}{}
-}
-
-var textMIMERegexp = []*regexp.Regexp{
-	regexp.MustCompile("^text/"),
-}
-
-var textMIMEType = map[string]struct{}{
-	"image/svg+xml":          {},
-	"application/json":       {},
-	"application/javascript": {},
-	"application/xml":        {},
+	DefaultContentClassifier.RegisterBinaryContentEncoding(t)
 }
 
+// RegisterTextMIMEType Register the text MIME type to DefaultContentClassifier.
 func RegisterTextMIMEType(t string) {
-	textMIMEType[t] = struct{}{}
+	DefaultContentClassifier.RegisterTextMIMEType(t)
 }
 
+// RegisterTextMIMERegexp Register the regexp matching text MIME types to DefaultContentClassifier.
 func RegisterTextMIMERegexp(r *regexp.Regexp) {
-	textMIMERegexp = append(textMIMERegexp, r)
+	DefaultContentClassifier.RegisterTextMIMERegexp(r)
 }
 
+// IsBinaryContent Classify the response headers with DefaultContentClassifier.
 func IsBinaryContent(h http.Header) bool {
-	for _, t := range h.Values(types.HTTPHeaderContentEncoding) {
-		if _, ok := binaryContentEncoding[t]; ok {
-			return true
-		}
-	}
-
-	return !IsTextContent(h.Get(types.HTTPHeaderContentType))
+	return DefaultContentClassifier.IsBinaryContent(h)
 }
 
+// IsTextContent Classify the content type with DefaultContentClassifier.
 func IsTextContent(contentType string) bool {
-	m, _, err := mime.ParseMediaType(contentType)
-	if err != nil {
-		return false
-	}
-
-	_, ok := textMIMEType[m]
-
-	if ok {
-		return true
-	}
-
-	for _, re := range textMIMERegexp {
-		if re.MatchString(m) {
-			textMIMEType[m] = struct{}{}
-			return true
-		}
-	}
-
-	return false
+	return DefaultContentClassifier.IsTextContent(contentType)
 }
 
 func SemicolonSeparatedHeaderMap(h http.Header) map[string]string {